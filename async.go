@@ -0,0 +1,226 @@
+// Copyright (c) 2018 souhup
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logx
+
+import (
+	"bufio"
+	"go.uber.org/zap/zapcore"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// overflow policies of asyncWriter.
+const (
+	overflowBlock      = "block"
+	overflowDropNewest = "drop_newest"
+	overflowDropOldest = "drop_oldest"
+)
+
+// asyncBufferSize is the size of the buffer between asyncWriter and its
+// output, so that entries are written in batches.
+const asyncBufferSize = 256 * 1024
+
+// asyncWriter queues entries and writes them to out in the background, so
+// that logging does not wait for a slow out unless the queue is full.
+type asyncWriter struct {
+	out      zapcore.WriteSyncer
+	overflow string
+	interval time.Duration
+	dropped  *uint64
+
+	// mu guards closed, and it is held by writers while they queue, so that
+	// nothing is queued after the background goroutine stops.
+	mu     sync.RWMutex
+	closed bool
+
+	queue chan []byte
+	syncs chan chan error
+	stop  chan struct{}
+	done  chan struct{}
+
+	// the fields below are only used by the background goroutine.
+	buf *bufio.Writer
+	err error
+}
+
+// newAsyncWriter starts writing to out in the background. Dropped entries
+// are counted by dropped.
+func newAsyncWriter(out zapcore.WriteSyncer, size int, interval time.Duration, overflow string, dropped *uint64) *asyncWriter {
+	if overflow == "" {
+		overflow = overflowBlock
+	}
+	it := &asyncWriter{
+		out:      out,
+		overflow: overflow,
+		interval: interval,
+		dropped:  dropped,
+		queue:    make(chan []byte, size),
+		syncs:    make(chan chan error),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+		buf:      bufio.NewWriterSize(out, asyncBufferSize),
+	}
+	go it.run()
+	return it
+}
+
+// Write queues a copy of p. If the queue is full, it waits, or drops the
+// newest or the oldest entry by the overflow policy. After Close, it writes
+// to out directly.
+func (it *asyncWriter) Write(p []byte) (int, error) {
+	it.mu.RLock()
+	defer it.mu.RUnlock()
+	if it.closed {
+		return it.out.Write(p)
+	}
+
+	entry := make([]byte, len(p))
+	copy(entry, p)
+	switch it.overflow {
+	case overflowDropNewest:
+		select {
+		case it.queue <- entry:
+		default:
+			atomic.AddUint64(it.dropped, 1)
+		}
+	case overflowDropOldest:
+		for queued := false; !queued; {
+			select {
+			case it.queue <- entry:
+				queued = true
+			default:
+				select {
+				case <-it.queue:
+					atomic.AddUint64(it.dropped, 1)
+				default:
+				}
+			}
+		}
+	default:
+		it.queue <- entry
+	}
+	return len(p), nil
+}
+
+// Sync waits until the entries queued before are written, and then syncs
+// out. It returns the first error of writing since the last Sync.
+func (it *asyncWriter) Sync() error {
+	it.mu.RLock()
+	defer it.mu.RUnlock()
+	if it.closed {
+		return it.out.Sync()
+	}
+
+	reply := make(chan error)
+	it.syncs <- reply
+	return <-reply
+}
+
+// Close writes the queued entries and stops the background goroutine.
+func (it *asyncWriter) Close() error {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+	if it.closed {
+		return nil
+	}
+	it.closed = true
+	close(it.stop)
+	<-it.done
+	return it.err
+}
+
+func (it *asyncWriter) run() {
+	defer close(it.done)
+	var tick <-chan time.Time
+	if it.interval > 0 {
+		ticker := time.NewTicker(it.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case entry := <-it.queue:
+			it.write(entry)
+			// without an interval, flush whenever the queue gets empty.
+			if it.interval == 0 && len(it.queue) == 0 {
+				it.flush()
+			}
+		case <-tick:
+			it.flush()
+		case reply := <-it.syncs:
+			it.drain()
+			reply <- it.sync()
+		case <-it.stop:
+			it.drain()
+			it.err = it.sync()
+			return
+		}
+	}
+}
+
+// write buffers an entry. An entry is never split between two writes to
+// out, so that a file is not rotated in the middle of it.
+func (it *asyncWriter) write(entry []byte) {
+	if len(entry) > it.buf.Available() {
+		it.flush()
+	}
+	if _, err := it.buf.Write(entry); err != nil {
+		it.fail(err)
+	}
+}
+
+// drain writes the entries in the queue now.
+func (it *asyncWriter) drain() {
+	for n := len(it.queue); n > 0; n-- {
+		select {
+		case entry := <-it.queue:
+			it.write(entry)
+		default:
+			return
+		}
+	}
+}
+
+func (it *asyncWriter) flush() {
+	if err := it.buf.Flush(); err != nil {
+		it.fail(err)
+		// drop the entries failed to be written, so that later ones can be.
+		it.buf.Reset(it.out)
+	}
+}
+
+func (it *asyncWriter) sync() error {
+	it.flush()
+	if err := it.out.Sync(); err != nil {
+		it.fail(err)
+	}
+	err := it.err
+	it.err = nil
+	return err
+}
+
+func (it *asyncWriter) fail(err error) {
+	if it.err == nil {
+		it.err = err
+	}
+}
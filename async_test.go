@@ -0,0 +1,150 @@
+// Copyright (c) 2018 souhup
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logx
+
+import (
+	"fmt"
+	"go.uber.org/zap/zapcore"
+	. "gopkg.in/check.v1"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// gatedWriter blocks writing until it is opened, and records what is
+// written.
+type gatedWriter struct {
+	entered chan struct{}
+	gate    chan struct{}
+
+	mu      sync.Mutex
+	written []string
+}
+
+func newGatedWriter() *gatedWriter {
+	return &gatedWriter{entered: make(chan struct{}, 100), gate: make(chan struct{})}
+}
+
+func (it *gatedWriter) Write(p []byte) (int, error) {
+	it.entered <- struct{}{}
+	<-it.gate
+	it.mu.Lock()
+	defer it.mu.Unlock()
+	it.written = append(it.written, strings.Split(strings.TrimSpace(string(p)), "\n")...)
+	return len(p), nil
+}
+
+func (it *gatedWriter) Sync() error {
+	return nil
+}
+
+func (it *gatedWriter) lines() []string {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+	return it.written
+}
+
+func (it *MySuite) TestAsync(c *C) {
+	filename := filepath.Join(c.MkDir(), "async.log")
+	logger, err := GetLoggerByConf(&Config{
+		MessageKey:    "msg",
+		Encoding:      "json",
+		Filename:      filename,
+		BufferSize:    8,
+		FlushInterval: time.Hour,
+	})
+	c.Assert(err, IsNil)
+
+	expected := make([]string, 0, 100)
+	for i := 0; i < 100; i++ {
+		logger.With("i", i).Info("async")
+		expected = append(expected, fmt.Sprintf(`{"msg":"async","i":%d}`, i))
+	}
+	logger.Flush()
+
+	data, err := ioutil.ReadFile(filename)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Split(strings.TrimSpace(string(data)), "\n"), DeepEquals, expected)
+	c.Assert(logger.Stats().Dropped, Equals, uint64(0))
+}
+
+func (it *MySuite) TestAsyncOverflow(c *C) {
+	for overflow, expected := range map[string][]string{
+		"drop_newest": {"0", "1", "2"},
+		"drop_oldest": {"0", "3", "4"},
+	} {
+		logger := GetLoggerByCore(zapcore.NewNopCore(), InfoLevel)
+		out := newGatedWriter()
+		async := newAsyncWriter(out, 2, 0, overflow, &logger.root.stats.dropped)
+
+		// the first entry is being written, and the queue is full after two
+		// more.
+		async.Write([]byte("0\n"))
+		<-out.entered
+		for i := 1; i <= 4; i++ {
+			async.Write([]byte(fmt.Sprintf("%d\n", i)))
+		}
+		c.Assert(logger.Stats().Dropped, Equals, uint64(2), Commentf("overflow %v", overflow))
+
+		close(out.gate)
+		c.Assert(async.Sync(), IsNil)
+		c.Assert(out.lines(), DeepEquals, expected, Commentf("overflow %v", overflow))
+		c.Assert(async.Close(), IsNil)
+
+		// it writes directly after being closed.
+		async.Write([]byte("5\n"))
+		c.Assert(out.lines()[len(out.lines())-1], Equals, "5")
+	}
+}
+
+func (it *MySuite) TestAsyncBlock(c *C) {
+	out := newGatedWriter()
+	var dropped uint64
+	async := newAsyncWriter(out, 1, 0, "block", &dropped)
+	async.Write([]byte("0\n"))
+	<-out.entered
+	async.Write([]byte("1\n"))
+
+	written := make(chan struct{})
+	go func() {
+		async.Write([]byte("2\n"))
+		close(written)
+	}()
+	select {
+	case <-written:
+		c.Fatal("write is not blocked by the full queue")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(out.gate)
+	<-written
+	c.Assert(async.Close(), IsNil)
+	c.Assert(out.lines(), DeepEquals, []string{"0", "1", "2"})
+	c.Assert(dropped, Equals, uint64(0))
+}
+
+func (it *MySuite) TestValidateAsync(c *C) {
+	config := &Config{MessageKey: "msg", Encoding: "json", BufferSize: -1, FlushInterval: -time.Second, Overflow: "drop"}
+	c.Assert(config.Validate(), ErrorMatches, "buffer_size must not be negative, but got -1; "+
+		"flush_interval must not be negative, but got -1s; "+
+		`overflow must be one of the block, drop_newest or drop_oldest, but got "drop"`)
+}
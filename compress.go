@@ -0,0 +1,105 @@
+// Copyright (c) 2018 souhup
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logx
+
+import (
+	"compress/gzip"
+	"fmt"
+	"github.com/klauspost/compress/zstd"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Compressor compresses rotated log files.
+type Compressor interface {
+	// Extension is appended to the name of compressed files, such as ".gz".
+	Extension() string
+
+	// NewWriter returns a writer which compresses everything written to it
+	// into w. Level 0 means the default level of the algorithm.
+	NewWriter(w io.Writer, level int) (io.WriteCloser, error)
+}
+
+var (
+	compressorMu sync.RWMutex
+	compressors  = map[string]Compressor{
+		"gzip": gzipCompressor{},
+		"zstd": zstdCompressor{},
+	}
+)
+
+// RegisterCompressor makes a Compressor available by name to the compression
+// option of Config. Registering an existing name replaces it.
+func RegisterCompressor(name string, c Compressor) {
+	compressorMu.Lock()
+	defer compressorMu.Unlock()
+	compressors[strings.ToLower(name)] = c
+}
+
+// getCompressor returns the Compressor selected by name, or nil if name is
+// "none" or empty.
+func getCompressor(name string) (Compressor, error) {
+	name = strings.ToLower(name)
+	if name == "" || name == "none" {
+		return nil, nil
+	}
+	compressorMu.RLock()
+	defer compressorMu.RUnlock()
+	c, ok := compressors[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown compression %q", name)
+	}
+	return c, nil
+}
+
+// compressedExtensions returns the extensions of all registered Compressors.
+func compressedExtensions() []string {
+	compressorMu.RLock()
+	defer compressorMu.RUnlock()
+	exts := make([]string, 0, len(compressors))
+	for _, c := range compressors {
+		exts = append(exts, c.Extension())
+	}
+	return exts
+}
+
+type gzipCompressor struct{}
+
+func (gzipCompressor) Extension() string { return ".gz" }
+
+func (gzipCompressor) NewWriter(w io.Writer, level int) (io.WriteCloser, error) {
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+	return gzip.NewWriterLevel(w, level)
+}
+
+type zstdCompressor struct{}
+
+func (zstdCompressor) Extension() string { return ".zst" }
+
+func (zstdCompressor) NewWriter(w io.Writer, level int) (io.WriteCloser, error) {
+	if level == 0 {
+		return zstd.NewWriter(w)
+	}
+	return zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+}
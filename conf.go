@@ -21,22 +21,91 @@
 package logx
 
 import (
-	"errors"
 	"fmt"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
-	"gopkg.in/natefinch/lumberjack.v2"
 	"os"
 	"time"
 )
 
+// closeDelay is how long replaced files are kept open, so that entries
+// checked before a reload can still be written to them.
+const closeDelay = time.Second
+
 // GetLoggerByConf constructs a new Logger by Config.
 func GetLoggerByConf(config *Config) (logger *Logger, err error) {
+	if err = config.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return
+	}
+
+	level := zap.NewAtomicLevelAt(zapcore.Level(config.Level))
+	root := &coreRoot{files: make(map[string]*rollingFile), level: level, stats: new(counters)}
+	if err = root.build(config); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return
+	}
+
+	return newLogger(root), nil
+}
+
+// GetLoggerByCore constructs a new Logger writing to core, such as a core of
+// zap or of logxtest. The level of the Logger decides which entries reach
+// core, and core may filter them further. Reload replaces core by the
+// outputs of Config.
+func GetLoggerByCore(core zapcore.Core, level Level) *Logger {
+	root := &coreRoot{
+		files: make(map[string]*rollingFile),
+		level: zap.NewAtomicLevelAt(zapcore.Level(level)),
+		stats: new(counters),
+	}
+	root.switchTo(&generation{core: core, errorOutput: os.Stderr})
+	return newLogger(root)
+}
+
+// newLogger constructs a Logger writing to the cores of root.
+func newLogger(root *coreRoot) (logger *Logger) {
+	opts := []zap.Option{zap.ErrorOutput(rootErrorOutput{root})}
+	opts = append(opts, zap.AddCaller(), zap.AddCallerSkip(2))
+
+	logger = new(Logger)
+	logger.zapLogger = zap.New(&switchCore{root: root}, opts...)
+	logger.sugar = logger.zapLogger.Sugar()
+	logger.level = root.level
+	logger.root = root
+	return
+}
+
+// Reload replaces the level, modules, keys, encoding and outputs of the Logger and of
+// all loggers derived from it by Config. If Config is invalid, it returns an
+// error and nothing is changed.
+func (it *Logger) Reload(config *Config) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	if err := it.root.build(config); err != nil {
+		return err
+	}
+	it.level.SetLevel(zapcore.Level(config.Level))
+	return nil
+}
+
+// sinkPlan is a sink of which the encoding and the output are checked.
+type sinkPlan struct {
+	sink    Sink
+	encoder zapcore.Encoder
+	file    *rollingConfig
+}
+
+// build constructs the cores described by config and switches to them.
+// Files used by both the current cores and the new ones are kept open.
+func (it *coreRoot) build(config *Config) error {
 	proConf := zapcore.EncoderConfig{
 		MessageKey:     config.MessageKey,
 		LevelKey:       config.LevelKey,
 		TimeKey:        config.TimeKey,
 		CallerKey:      config.CallerKey,
+		NameKey:        config.NameKey,
 		LineEnding:     zapcore.DefaultLineEnding,
 		EncodeLevel:    zapcore.CapitalLevelEncoder,
 		EncodeTime:     timeEncoder,
@@ -44,39 +113,205 @@ func GetLoggerByConf(config *Config) (logger *Logger, err error) {
 		EncodeCaller:   zapcore.ShortCallerEncoder,
 	}
 
-	// choose the type of encoding.
-	var encoder zapcore.Encoder
-	if config.Encoding == "json" {
-		encoder = zapcore.NewJSONEncoder(proConf)
-	} else if config.Encoding == "console" {
-		encoder = zapcore.NewConsoleEncoder(proConf)
-	} else {
-		err = errors.New("encoding must be one of the json, console or simple")
-		fmt.Fprintln(os.Stderr, err.Error())
-		return
+	sinks := config.Sinks
+	if len(sinks) == 0 {
+		output := config.Filename
+		if output == "" {
+			output = "stdout"
+		}
+		sinks = []Sink{{Output: output, Encoding: config.Encoding, Rotation: config.rotation()}}
 	}
 
-	// writer logs to rolling files
-	zapWriter := zapcore.AddSync(&lumberjack.Logger{
-		Filename:   config.Filename,
-		MaxSize:    config.MaxSize,
-		MaxAge:     config.MaxAge,
-		MaxBackups: config.MaxBackups,
-		LocalTime:  config.LocalTime,
-	})
-	output := zapcore.NewMultiWriteSyncer(zapWriter)
-	if config.Filename == "" {
-		output = os.Stdout
+	// check every sink before touching the files in use.
+	plans := make([]sinkPlan, 0, len(sinks))
+	for _, sink := range sinks {
+		encoding := sink.Encoding
+		if encoding == "" {
+			encoding = config.Encoding
+		}
+		encoder, err := newEncoder(encoding, proConf)
+		if err != nil {
+			return err
+		}
+		file, err := newRollingConfig(sink)
+		if err != nil {
+			return err
+		}
+		plans = append(plans, sinkPlan{sink: sink, encoder: encoder, file: file})
+	}
+	var redactor *redactor
+	if config.Redact != nil {
+		var err error
+		if redactor, err = newRedactor(config.Redact); err != nil {
+			return err
+		}
 	}
 
-	newCore := zapcore.NewCore(encoder, output, zap.NewAtomicLevelAt(zapcore.Level(config.Level)))
-	opts := []zap.Option{zap.ErrorOutput(zapWriter)}
-	opts = append(opts, zap.AddCaller(), zap.AddCallerSkip(2))
+	it.mu.Lock()
+	defer it.mu.Unlock()
 
-	logger = new(Logger)
-	logger.zapLogger = zap.New(newCore, opts...)
-	logger.sugar = logger.zapLogger.Sugar()
-	return
+	// internal errors of zap are written to the first file.
+	var zapWriter zapcore.WriteSyncer = os.Stderr
+	files := make(map[string]*rollingFile)
+	asyncs := make(map[string]*asyncWriter)
+	cores := make([]zapcore.Core, 0, len(plans))
+	for _, plan := range plans {
+		var output zapcore.WriteSyncer
+		switch {
+		case plan.file == nil && plan.sink.Output == "stdout":
+			output = os.Stdout
+		case plan.file == nil:
+			output = os.Stderr
+		default:
+			file, ok := files[plan.file.filename]
+			if !ok {
+				if file, ok = it.files[plan.file.filename]; ok {
+					file.configure(*plan.file)
+				} else {
+					file = &rollingFile{rollingConfig: *plan.file}
+				}
+				files[plan.file.filename] = file
+			}
+			if zapWriter == os.Stderr {
+				zapWriter = file
+			}
+			output = file
+		}
+		// each output is written in the background by one asyncWriter.
+		if config.BufferSize > 0 {
+			async, ok := asyncs[plan.sink.Output]
+			if !ok {
+				async = newAsyncWriter(output, config.BufferSize, config.FlushInterval, config.Overflow, &it.stats.dropped)
+				asyncs[plan.sink.Output] = async
+			}
+			output = async
+		}
+		cores = append(cores, zapcore.NewCore(plan.encoder, output, sinkLevel(plan.sink.Level)))
+	}
+
+	core := zapcore.NewTee(cores...)
+	if config.Redact != nil {
+		core = &redactCore{Core: core, redactor: redactor, errorOutput: zapWriter}
+	}
+	if config.Sampling != nil {
+		core = newSamplerCore(core, config.Sampling, &it.stats.sampled)
+	}
+	gen := &generation{core: core, errorOutput: zapWriter}
+	for _, key := range []string{config.MessageKey, config.LevelKey, config.TimeKey, config.CallerKey, config.NameKey} {
+		if key != "" {
+			gen.keys = append(gen.keys, key)
+		}
+	}
+	if config.DedupWindow > 0 {
+		gen.dedup = newDedupCore(core, config.DedupWindow, zapWriter, &it.stats.deduped)
+		gen.core = gen.dedup
+	}
+	previous, _ := it.current.Load().(*generation)
+	it.switchTo(gen)
+	it.setModules(config.Modules)
+	// the repeated entries of the replaced cores are summarized to their
+	// outputs, which are still open.
+	if previous != nil && previous.dedup != nil {
+		previous.dedup.flush()
+	}
+
+	// the writers of the replaced cores are stopped before closing the files
+	// no longer used.
+	retired := it.asyncs
+	var closing []*rollingFile
+	for name, file := range it.files {
+		if _, ok := files[name]; !ok {
+			closing = append(closing, file)
+		}
+	}
+	if len(retired) > 0 || len(closing) > 0 {
+		time.AfterFunc(closeDelay, func() {
+			for _, async := range retired {
+				async.Close()
+			}
+			for _, file := range closing {
+				file.Sync()
+				file.Close()
+			}
+		})
+	}
+	it.files = files
+	it.asyncs = nil
+	for _, async := range asyncs {
+		it.asyncs = append(it.asyncs, async)
+	}
+	return nil
+}
+
+// rotation returns the rotation of Filename.
+func (it *Config) rotation() Rotation {
+	return Rotation{
+		MaxSize:          it.MaxSize,
+		MaxAge:           it.MaxAge,
+		MaxBackups:       it.MaxBackups,
+		LocalTime:        it.LocalTime,
+		RotateInterval:   it.RotateInterval,
+		RotateAlign:      it.RotateAlign,
+		BackupTimeFormat: it.BackupTimeFormat,
+		Compress:         it.Compress,
+		Compression:      it.Compression,
+		CompressionLevel: it.CompressionLevel,
+	}
+}
+
+// sinkLevel enables the entries at or above min, and all entries if min is
+// nil. The level of the Logger and the levels of modules are checked before
+// the cores of sinks, see switchCore.
+func sinkLevel(min *Level) zapcore.LevelEnabler {
+	if min == nil {
+		return zapcore.DebugLevel
+	}
+	return zapcore.Level(*min)
+}
+
+// newEncoder chooses the type of encoding.
+func newEncoder(encoding string, conf zapcore.EncoderConfig) (zapcore.Encoder, error) {
+	switch encoding {
+	case "json":
+		return zapcore.NewJSONEncoder(conf), nil
+	case "console":
+		return zapcore.NewConsoleEncoder(conf), nil
+	}
+	return nil, fmt.Errorf("encoding must be one of the json or console, but got %q", encoding)
+}
+
+// newRollingConfig returns the settings of the file of sink, or nil if the
+// output of sink is stdout or stderr.
+func newRollingConfig(sink Sink) (*rollingConfig, error) {
+	switch sink.Output {
+	case "stdout", "stderr":
+		return nil, nil
+	case "":
+		return nil, fmt.Errorf("output of sink must not be empty")
+	}
+
+	// choose the algorithm compressing rotated files.
+	compression := sink.Compression
+	if compression == "" && sink.Compress {
+		compression = "gzip"
+	}
+	compressor, err := getCompressor(compression)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rollingConfig{
+		filename:      sink.Output,
+		maxSize:       sink.MaxSize,
+		maxAge:        sink.MaxAge,
+		maxBackups:    sink.MaxBackups,
+		localTime:     sink.LocalTime,
+		compressor:    compressor,
+		compressLevel: sink.CompressionLevel,
+		interval:      sink.RotateInterval,
+		align:         sink.RotateAlign,
+		timeFormat:    sink.BackupTimeFormat,
+	}, nil
 }
 
 // timeEncoder sets the time format
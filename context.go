@@ -0,0 +1,56 @@
+// Copyright (c) 2018 souhup
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logx
+
+import "context"
+
+// contextKey is the key of the Logger stored in a context. It is a private
+// type, so that it never collides with the keys of other packages.
+type contextKey struct{}
+
+// NewContext returns a copy of ctx holding logger. The logger is used by the
+// methods with a context, such as Infoc, and returned by FromContext.
+func NewContext(ctx context.Context, logger Log) context.Context {
+	if ctx == nil {
+		ctx = context.TODO()
+	}
+	// the caller skip is of whoever logs by the logger later.
+	if log, ok := logger.(*Logger); ok && log.callerSkip != 0 {
+		logger = log.WithCallerSkip(-log.callerSkip)
+	}
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the logger held by ctx, or X if there is none.
+func FromContext(ctx context.Context) Log {
+	return fromContext(ctx, X)
+}
+
+// fromContext returns the logger held by ctx, or fallback if there is none.
+func fromContext(ctx context.Context, fallback Log) Log {
+	if ctx == nil {
+		return fallback
+	}
+	if log, ok := ctx.Value(contextKey{}).(Log); ok && log != nil {
+		return log
+	}
+	return fallback
+}
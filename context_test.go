@@ -0,0 +1,51 @@
+// Copyright (c) 2018 souhup
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logx
+
+import (
+	"context"
+	. "gopkg.in/check.v1"
+	"io/ioutil"
+	"path/filepath"
+)
+
+func (it *MySuite) TestContext(c *C) {
+	c.Assert(FromContext(context.Background()), Equals, X)
+	c.Assert(FromContext(nil), Equals, X)
+
+	filename := filepath.Join(c.MkDir(), "context.log")
+	logger, err := GetLoggerByConf(&Config{MessageKey: "msg", Encoding: "json", Filename: filename})
+	c.Assert(err, IsNil)
+	request := logger.With("request", "r1")
+	ctx := NewContext(context.Background(), request)
+	c.Assert(FromContext(ctx), Equals, request)
+
+	// a string key of another package does not collide with the Logger.
+	ctx = context.WithValue(ctx, "_logx", "other")
+	FromContext(ctx).Info("from context")
+	X.Infoc(logger.Withc(ctx, "step", 1), "by Withc")
+	logger.Flush()
+
+	data, err := ioutil.ReadFile(filename)
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, `{"msg":"from context","request":"r1"}`+"\n"+
+		`{"msg":"by Withc","request":"r1","step":1}`+"\n")
+}
@@ -0,0 +1,182 @@
+// Copyright (c) 2018 souhup
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logx
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"sync"
+	"sync/atomic"
+)
+
+// coreRoot holds the cores built from the Config of a Logger, and the levels
+// deciding which entries reach them. They can be replaced at runtime, and the
+// switchCores of the Logger and of all loggers derived from it follow the
+// replacement.
+type coreRoot struct {
+	mu      sync.Mutex
+	files   map[string]*rollingFile
+	current atomic.Value // *generation
+	level   zap.AtomicLevel
+	modules atomic.Value // *moduleLevels
+	asyncs  []*asyncWriter
+	stats   *counters
+
+	// limiters are the limiters of Every, Limit and Once by their keys,
+	// and limitersSweptAt is when the idle ones were last dropped, in
+	// nanoseconds. It is only accessed atomically.
+	limiters        sync.Map
+	limitersSweptAt int64
+}
+
+// counters are the numbers reported by Logger.Stats. They are only accessed
+// atomically.
+type counters struct {
+	dropped    uint64
+	sampled    uint64
+	suppressed uint64
+	deduped    uint64
+}
+
+// Stats are the numbers about the entries of a Logger, counted since it is
+// constructed.
+type Stats struct {
+	// Dropped is the number of entries dropped because the queue of an
+	// output is full, see Config.Overflow.
+	Dropped uint64
+
+	// Sampled is the number of entries sampled away, see Config.Sampling.
+	Sampled uint64
+
+	// Suppressed is the number of entries suppressed by the limits of
+	// Logger.Every, Logger.Limit and Logger.Once.
+	Suppressed uint64
+
+	// Deduplicated is the number of repeated entries collapsed, see
+	// Config.DedupWindow.
+	Deduplicated uint64
+}
+
+// Stats returns the numbers about the entries of the Logger and of all
+// loggers derived from it.
+func (it *Logger) Stats() Stats {
+	return Stats{
+		Dropped:      atomic.LoadUint64(&it.root.stats.dropped),
+		Sampled:      atomic.LoadUint64(&it.root.stats.sampled),
+		Suppressed:   atomic.LoadUint64(&it.root.stats.suppressed),
+		Deduplicated: atomic.LoadUint64(&it.root.stats.deduped),
+	}
+}
+
+// generation is a set of cores built from one Config.
+type generation struct {
+	core        zapcore.Core
+	errorOutput zapcore.WriteSyncer
+	dedup       *dedupCore // nil if entries are not deduplicated
+
+	// keys are the keys written by the encoders, such as the key of message.
+	keys []string
+}
+
+// switchCore is a zapcore.Core which delegates to the current generation of
+// its root, with the fields added by With.
+type switchCore struct {
+	root   *coreRoot
+	fields []zapcore.Field
+	cache  atomic.Value // *switchCache
+}
+
+// switchCache is the core of a generation with the fields of a switchCore.
+type switchCache struct {
+	gen  *generation
+	core zapcore.Core
+}
+
+// switchTo replaces the current generation.
+func (it *coreRoot) switchTo(gen *generation) {
+	it.current.Store(gen)
+}
+
+// load returns the core of the current generation with the fields.
+func (it *switchCore) load() zapcore.Core {
+	gen := it.root.current.Load().(*generation)
+	if cached, ok := it.cache.Load().(*switchCache); ok && cached.gen == gen {
+		return cached.core
+	}
+	core := gen.core
+	if len(it.fields) > 0 {
+		core = core.With(it.fields)
+	}
+	it.cache.Store(&switchCache{gen: gen, core: core})
+	return core
+}
+
+func (it *switchCore) Enabled(level zapcore.Level) bool {
+	return it.root.enabled(level) && it.load().Enabled(level)
+}
+
+func (it *switchCore) With(fields []zapcore.Field) zapcore.Core {
+	all := make([]zapcore.Field, 0, len(it.fields)+len(fields))
+	all = append(all, it.fields...)
+	all = append(all, fields...)
+	return &switchCore{root: it.root, fields: all}
+}
+
+// Check adds the core of the current generation to ce, so an entry checked
+// before a switch is still written to the outputs it was checked against.
+func (it *switchCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !it.root.enabledFor(entry.LoggerName, entry.Level) {
+		return ce
+	}
+	return it.load().Check(entry, ce)
+}
+
+func (it *switchCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	return it.load().Write(entry, fields)
+}
+
+func (it *switchCore) Sync() error {
+	return it.load().Sync()
+}
+
+// rootErrorOutput writes internal errors of zap to the error output of the
+// current generation.
+type rootErrorOutput struct {
+	root *coreRoot
+}
+
+func (it rootErrorOutput) Write(p []byte) (int, error) {
+	return it.root.current.Load().(*generation).errorOutput.Write(p)
+}
+
+func (it rootErrorOutput) Sync() error {
+	return it.root.current.Load().(*generation).errorOutput.Sync()
+}
+
+// writeChecked writes the entry to the cores of core enabling it, for a core
+// wrapping others which is only called by Write. Errors are written to
+// errorOutput, like those of Logger.
+func writeChecked(core zapcore.Core, entry zapcore.Entry, fields []zapcore.Field, errorOutput zapcore.WriteSyncer) {
+	if ce := core.Check(entry, nil); ce != nil {
+		ce.ErrorOutput = errorOutput
+		ce.Write(fields...)
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright (c) 2018 souhup
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logx
+
+import (
+	"fmt"
+	"go.uber.org/zap/zapcore"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// dedupCore is a zapcore.Core which collapses consecutive entries of the same
+// level, name, message and fields within a window, like syslog. The first
+// entry is written, and the repeated ones are counted and summarized by an
+// entry "last message repeated N times" when a different entry comes, the
+// same entry comes after the window, or the core is synced.
+type dedupCore struct {
+	zapcore.Core
+	window      time.Duration
+	keyEncoder  zapcore.Encoder // encodes the fields into the key of entries
+	state       *dedupState
+	errorOutput zapcore.WriteSyncer
+	deduped     *uint64
+}
+
+// dedupState is the last entry written, shared by the cores derived by With.
+type dedupState struct {
+	mu    sync.Mutex
+	key   string
+	since time.Time
+	last  zapcore.Entry
+	core  zapcore.Core // the wrapped core with the fields of last
+	count int
+}
+
+// newDedupCore wraps core by collapsing the entries repeated within window.
+// The repeated entries are counted by deduped.
+func newDedupCore(core zapcore.Core, window time.Duration, errorOutput zapcore.WriteSyncer, deduped *uint64) *dedupCore {
+	return &dedupCore{
+		Core:        core,
+		window:      window,
+		keyEncoder:  zapcore.NewJSONEncoder(zapcore.EncoderConfig{}),
+		state:       new(dedupState),
+		errorOutput: errorOutput,
+		deduped:     deduped,
+	}
+}
+
+func (it *dedupCore) With(fields []zapcore.Field) zapcore.Core {
+	keyEncoder := it.keyEncoder.Clone()
+	for _, field := range fields {
+		field.AddTo(keyEncoder)
+	}
+	return &dedupCore{
+		Core:        it.Core.With(fields),
+		window:      it.window,
+		keyEncoder:  keyEncoder,
+		state:       it.state,
+		errorOutput: it.errorOutput,
+		deduped:     it.deduped,
+	}
+}
+
+// Check adds the core itself to ce, since the fields of the entry are only
+// known by Write.
+func (it *dedupCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !it.Enabled(entry.Level) {
+		return ce
+	}
+	return ce.AddCore(entry, it)
+}
+
+func (it *dedupCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	key, err := it.key(entry, fields)
+	if err != nil {
+		return err
+	}
+
+	state := it.state
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	if key == state.key && entry.Time.Sub(state.since) < it.window {
+		state.last, state.core = entry, it.Core
+		state.count++
+		atomic.AddUint64(it.deduped, 1)
+		return nil
+	}
+	it.summarize()
+	state.key, state.since = key, entry.Time
+	writeChecked(it.Core, entry, fields, it.errorOutput)
+	return nil
+}
+
+func (it *dedupCore) Sync() error {
+	it.flush()
+	return it.Core.Sync()
+}
+
+// flush writes the summary of the repeated entries, if any.
+func (it *dedupCore) flush() {
+	it.state.mu.Lock()
+	defer it.state.mu.Unlock()
+	it.summarize()
+}
+
+// summarize writes the summary of the repeated entries, if any. The caller
+// must hold the lock of the state.
+func (it *dedupCore) summarize() {
+	state := it.state
+	if state.count == 0 {
+		return
+	}
+	entry := state.last
+	entry.Message = fmt.Sprintf("last message repeated %d times", state.count)
+	writeChecked(state.core, entry, nil, it.errorOutput)
+	state.key, state.count, state.core = "", 0, nil
+}
+
+// key identifies the entries which are the same.
+func (it *dedupCore) key(entry zapcore.Entry, fields []zapcore.Field) (string, error) {
+	buf, err := it.keyEncoder.EncodeEntry(zapcore.Entry{}, fields)
+	if err != nil {
+		return "", err
+	}
+	defer buf.Free()
+	return entry.Level.String() + "\x00" + entry.LoggerName + "\x00" + entry.Message + "\x00" + buf.String(), nil
+}
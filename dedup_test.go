@@ -0,0 +1,92 @@
+// Copyright (c) 2018 souhup
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logx
+
+import (
+	. "gopkg.in/check.v1"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+func (it *MySuite) TestDedup(c *C) {
+	filename := filepath.Join(c.MkDir(), "dedup.log")
+	logger, err := GetLoggerByConf(&Config{
+		MessageKey:  "msg",
+		LevelKey:    "level",
+		Encoding:    "console",
+		Filename:    filename,
+		DedupWindow: time.Hour,
+	})
+	c.Assert(err, IsNil)
+
+	db := logger.With("db", "main")
+	for i := 0; i < 4; i++ {
+		db.Error("connection refused")
+	}
+	// the fields are part of the entry.
+	db.With("retry", 1).Error("connection refused")
+	db.With("retry", 1).Error("connection refused")
+	logger.Warn("connection refused")
+	logger.Warn("connection refused")
+	logger.Flush()
+
+	data, err := ioutil.ReadFile(filename)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Split(strings.TrimSpace(string(data)), "\n"), DeepEquals, []string{
+		"ERROR\tconnection refused\t{\"db\": \"main\"}",
+		"ERROR\tlast message repeated 3 times\t{\"db\": \"main\"}",
+		"ERROR\tconnection refused\t{\"db\": \"main\", \"retry\": 1}",
+		"ERROR\tlast message repeated 1 times\t{\"db\": \"main\", \"retry\": 1}",
+		"WARN\tconnection refused",
+		"WARN\tlast message repeated 1 times",
+	})
+	c.Assert(logger.Stats().Deduplicated, Equals, uint64(5))
+}
+
+func (it *MySuite) TestDedupWindow(c *C) {
+	filename := filepath.Join(c.MkDir(), "window.log")
+	logger, err := GetLoggerByConf(&Config{
+		MessageKey:  "msg",
+		Encoding:    "json",
+		Filename:    filename,
+		DedupWindow: 20 * time.Millisecond,
+	})
+	c.Assert(err, IsNil)
+
+	logger.Info("down")
+	logger.Info("down")
+	time.Sleep(30 * time.Millisecond)
+	logger.Info("down")
+	logger.Flush()
+
+	data, err := ioutil.ReadFile(filename)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Split(strings.TrimSpace(string(data)), "\n"), DeepEquals, []string{
+		`{"msg":"down"}`,
+		`{"msg":"last message repeated 1 times"}`,
+		`{"msg":"down"}`,
+	})
+
+	config := &Config{MessageKey: "msg", Encoding: "json", DedupWindow: -time.Second}
+	c.Assert(config.Validate(), ErrorMatches, "dedup_window must not be negative, but got -1s")
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2018 souhup
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logx
+
+import (
+	"fmt"
+	"go.uber.org/multierr"
+	"gopkg.in/yaml.v2"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// EnvPrefix is the prefix of the environment variables read by GetLogger,
+// GetLoggerFromEnv and InitWatch.
+var EnvPrefix = "LOGX"
+
+// LoadEnv overrides the fields of Config by the environment variables named
+// by prefix, an underscore and the upper-cased key of the field in the
+// configuration file, such as LOGX_LEVEL, LOGX_ENCODING and LOGX_FILE_NAME.
+// Fields whose variable is not set are kept.
+//
+// Values of string fields are taken as they are, and the others are parsed
+// as YAML, so LOGX_SINKS='[{output: stdout, encoding: console}]' is valid.
+func (it *Config) LoadEnv(prefix string) (err error) {
+	if prefix != "" {
+		prefix += "_"
+	}
+	value := reflect.ValueOf(it).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		key := strings.Split(value.Type().Field(i).Tag.Get("yaml"), ",")[0]
+		if key == "" || key == "-" {
+			continue
+		}
+		name := prefix + strings.ToUpper(key)
+		env, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+
+		field := value.Field(i)
+		if field.Kind() == reflect.String {
+			field.SetString(env)
+			continue
+		}
+		// an empty variable is an empty string, since an empty document
+		// would keep the field.
+		if env == "" {
+			env = "''"
+		}
+		if e := yaml.UnmarshalStrict([]byte(env), field.Addr().Interface()); e != nil {
+			err = multierr.Append(err, fmt.Errorf("%v, error: %v", name, e))
+		}
+	}
+	return
+}
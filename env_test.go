@@ -0,0 +1,113 @@
+// Copyright (c) 2018 souhup
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logx
+
+import (
+	"go.uber.org/multierr"
+	. "gopkg.in/check.v1"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+func (it *MySuite) TestLoadEnv(c *C) {
+	defer setEnv(map[string]string{
+		"APP_LEVEL":           "warn",
+		"APP_MAX_SIZE":        "10",
+		"APP_LOCAL_TIME":      "true",
+		"APP_ROTATE_INTERVAL": "1h",
+		"APP_SINKS":           "[{output: stderr, level: error}]",
+	})()
+
+	config := DefaultConfig()
+	c.Assert(config.LoadEnv("APP"), IsNil)
+	c.Assert(config.Level, Equals, WarnLevel)
+	c.Assert(config.MaxSize, Equals, 10)
+	c.Assert(config.LocalTime, Equals, true)
+	c.Assert(config.RotateInterval, Equals, time.Hour)
+	c.Assert(config.Sinks, HasLen, 1)
+	c.Assert(*config.Sinks[0].Level, Equals, ErrorLevel)
+	c.Assert(config.Encoding, Equals, "json")
+
+	defer setEnv(map[string]string{"APP_MAX_AGE": "week", "APP_LEVEL": "verbose"})()
+	errs := multierr.Errors(DefaultConfig().LoadEnv("APP"))
+	c.Assert(errs, HasLen, 2)
+	c.Assert(errs[0], ErrorMatches, `(?s)APP_LEVEL, error: .*unknown level "verbose", .*`)
+	c.Assert(errs[1], ErrorMatches, `(?s)APP_MAX_AGE, error: .*`)
+}
+
+func (it *MySuite) TestEnvPrecedence(c *C) {
+	dir := c.MkDir()
+	path := filepath.Join(dir, "logs.yml")
+	filename := filepath.Join(dir, "test.log")
+	c.Assert(ioutil.WriteFile(path, []byte("level: error\ntime_key: ''\nfile_name: ignored.log\n"), 0644), IsNil)
+	defer setEnv(map[string]string{"LOGX_FILE_NAME": filename, "LOGX_CALLER_KEY": ""})()
+
+	logger, err := GetLogger(path)
+	c.Assert(err, IsNil)
+	logger.Warn("hidden")
+	logger.Error("shown")
+	logger.Flush()
+
+	// level from the file, keys from the defaults and the file, and the
+	// file name from the environment.
+	data, err := ioutil.ReadFile(filename)
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, `{"level":"ERROR","msg":"shown"}`+"\n")
+}
+
+func (it *MySuite) TestGetLoggerFromEnv(c *C) {
+	filename := filepath.Join(c.MkDir(), "test.log")
+	defer setEnv(map[string]string{"LOGX_FILE_NAME": filename, "LOGX_ENCODING": "console", "LOGX_TIME_KEY": "", "LOGX_CALLER_KEY": ""})()
+
+	logger, err := GetLoggerFromEnv()
+	c.Assert(err, IsNil)
+	logger.Info("test GetLoggerFromEnv")
+	logger.Flush()
+
+	data, err := ioutil.ReadFile(filename)
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "INFO\ttest GetLoggerFromEnv\n")
+}
+
+// setEnv sets the environment variables, and returns a function restoring
+// them.
+func setEnv(env map[string]string) func() {
+	old := make(map[string]*string)
+	for key, value := range env {
+		if v, ok := os.LookupEnv(key); ok {
+			old[key] = &v
+		} else {
+			old[key] = nil
+		}
+		os.Setenv(key, value)
+	}
+	return func() {
+		for key, value := range old {
+			if value == nil {
+				os.Unsetenv(key)
+			} else {
+				os.Setenv(key, *value)
+			}
+		}
+	}
+}
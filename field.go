@@ -0,0 +1,176 @@
+// Copyright (c) 2018 souhup
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logx
+
+import (
+	"fmt"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"time"
+)
+
+// Field is a typed key-value pair of a log entry. Logging by fields, such as
+// InfoFields, skips fmt and reflection, and does not allocate for most types.
+type Field = zapcore.Field
+
+// ObjectMarshaler lets a type add itself to an entry as an object, see
+// Object.
+type ObjectMarshaler = zapcore.ObjectMarshaler
+
+// ArrayMarshaler lets a type add itself to an entry as an array, see Array.
+type ArrayMarshaler = zapcore.ArrayMarshaler
+
+// ObjectEncoder is the encoder passed to ObjectMarshaler.
+type ObjectEncoder = zapcore.ObjectEncoder
+
+// ArrayEncoder is the encoder passed to ArrayMarshaler.
+type ArrayEncoder = zapcore.ArrayEncoder
+
+// String constructs a field with a string.
+func String(key string, value string) Field {
+	return zap.String(key, value)
+}
+
+// Strings constructs a field with a slice of strings.
+func Strings(key string, value []string) Field {
+	return zap.Strings(key, value)
+}
+
+// Int constructs a field with an int.
+func Int(key string, value int) Field {
+	return zap.Int(key, value)
+}
+
+// Int64 constructs a field with an int64.
+func Int64(key string, value int64) Field {
+	return zap.Int64(key, value)
+}
+
+// Uint constructs a field with a uint.
+func Uint(key string, value uint) Field {
+	return zap.Uint(key, value)
+}
+
+// Uint64 constructs a field with a uint64.
+func Uint64(key string, value uint64) Field {
+	return zap.Uint64(key, value)
+}
+
+// Float64 constructs a field with a float64.
+func Float64(key string, value float64) Field {
+	return zap.Float64(key, value)
+}
+
+// Bool constructs a field with a bool.
+func Bool(key string, value bool) Field {
+	return zap.Bool(key, value)
+}
+
+// Duration constructs a field with a time.Duration, encoded in seconds.
+func Duration(key string, value time.Duration) Field {
+	return zap.Duration(key, value)
+}
+
+// Time constructs a field with a time.Time, encoded as the time of entries.
+func Time(key string, value time.Time) Field {
+	return zap.Time(key, value)
+}
+
+// Err constructs a field with an error under the key "error". A nil error
+// adds nothing.
+func Err(err error) Field {
+	return zap.Error(err)
+}
+
+// NamedErr is same as Err, but under the key.
+func NamedErr(key string, err error) Field {
+	return zap.NamedError(key, err)
+}
+
+// Stringer constructs a field with the String of value, called only if the
+// entry is written.
+func Stringer(key string, value fmt.Stringer) Field {
+	return zap.Stringer(key, value)
+}
+
+// Object constructs a field with an object marshaled by itself.
+func Object(key string, value ObjectMarshaler) Field {
+	return zap.Object(key, value)
+}
+
+// Array constructs a field with an array marshaled by itself.
+func Array(key string, value ArrayMarshaler) Field {
+	return zap.Array(key, value)
+}
+
+// Any constructs a field with any value, choosing the typed constructor
+// above by its type, and falling back to reflection.
+func Any(key string, value interface{}) Field {
+	return zap.Any(key, value)
+}
+
+// WithFields adds fields and constructs a new Logger.
+func (it *Logger) WithFields(fields ...Field) Log {
+	log := it.derive(it.zapLogger.With(fields...).Sugar())
+	for _, field := range fields {
+		log.keys = append(log.keys, field.Key)
+	}
+	return log
+}
+
+// DebugFields logs a message with fields at debug level.
+func (it *Logger) DebugFields(msg string, fields ...Field) {
+	logFields(it, zapcore.DebugLevel, msg, fields)
+}
+
+// InfoFields logs a message with fields at info level.
+func (it *Logger) InfoFields(msg string, fields ...Field) {
+	logFields(it, zapcore.InfoLevel, msg, fields)
+}
+
+// WarnFields logs a message with fields at warn level.
+func (it *Logger) WarnFields(msg string, fields ...Field) {
+	logFields(it, zapcore.WarnLevel, msg, fields)
+}
+
+// ErrorFields logs a message with fields at error level.
+func (it *Logger) ErrorFields(msg string, fields ...Field) {
+	logFields(it, zapcore.ErrorLevel, msg, fields)
+}
+
+// FatalFields logs a message with fields at fatal level, and then calls
+// os.Exit(1).
+func (it *Logger) FatalFields(msg string, fields ...Field) {
+	logFields(it, zapcore.FatalLevel, msg, fields)
+}
+
+// PanicFields logs a message with fields at panic level, and then panics.
+func (it *Logger) PanicFields(msg string, fields ...Field) {
+	logFields(it, zapcore.PanicLevel, msg, fields)
+}
+
+// logFields writes an entry of fields. It is called by the methods above
+// only, so that the caller is skipped by the same depth as generate.
+func logFields(self *Logger, level zapcore.Level, msg string, fields []Field) {
+	if ce := self.zapLogger.Check(level, msg); ce != nil {
+		ce.Write(fields...)
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2018 souhup
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logx
+
+import (
+	"errors"
+	. "gopkg.in/check.v1"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func (it *MySuite) TestFields(c *C) {
+	filename := filepath.Join(c.MkDir(), "fields.log")
+	logger, err := GetLoggerByConf(&Config{
+		Level:      InfoLevel,
+		MessageKey: "msg",
+		CallerKey:  "caller",
+		Encoding:   "json",
+		Filename:   filename,
+	})
+	c.Assert(err, IsNil)
+
+	below := callersBelow()
+	logger.DebugFields("hidden", String("a", "1"))
+	logger.InfoFields("typed", String("s", "x"), Int("i", 1), Bool("b", true),
+		Duration("d", 1500*time.Millisecond), Err(errors.New("failed")), Strings("ss", []string{"y"}))
+	logger.WithFields(Int64("request", 7)).With("user", "u").WarnFields("derived", Float64("f", 0.5))
+	logger.With("user", "u").ErrorFields("sugared")
+	logger.Flush()
+
+	data, err := ioutil.ReadFile(filename)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Split(strings.TrimSpace(string(data)), "\n"), DeepEquals, []string{
+		`{"caller":"` + below(2) + `","msg":"typed","s":"x","i":1,"b":true,"d":1.5,"error":"failed","ss":["y"]}`,
+		`{"caller":"` + below(4) + `","msg":"derived","request":7,"user":"u","f":0.5}`,
+		`{"caller":"` + below(5) + `","msg":"sugared","user":"u"}`,
+	})
+}
+
+func (it *MySuite) TestFieldsAllocs(c *C) {
+	filename := filepath.Join(c.MkDir(), "allocs.log")
+	logger, err := GetLoggerByConf(&Config{Level: InfoLevel, MessageKey: "msg", Encoding: "json", Filename: filename})
+	c.Assert(err, IsNil)
+	defer logger.Flush()
+
+	// only the slice of fields escapes when the level is disabled.
+	disabled := testing.AllocsPerRun(100, func() {
+		logger.DebugFields("hidden", String("s", "x"), Int("i", 1))
+	})
+	c.Assert(disabled <= 1, Equals, true, Commentf("allocs %v", disabled))
+
+	fields := testing.AllocsPerRun(100, func() {
+		logger.InfoFields("shown", String("s", "x"), Int("i", 1))
+	})
+	sugared := testing.AllocsPerRun(100, func() {
+		logger.Info("shown", "x", 1)
+	})
+	c.Assert(fields < sugared, Equals, true, Commentf("allocs %v and %v", fields, sugared))
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2018 souhup
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logx
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// formatOf returns the format of the configuration file by its extension,
+// and files with an unknown extension are yaml.
+func formatOf(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return "json"
+	case ".toml":
+		return "toml"
+	}
+	return "yaml"
+}
+
+// unmarshalConfig unmarshals data in the format onto config. Json and toml
+// are converted to yaml first, so that all formats share the same keys and
+// strict rules, e.g. rotate_interval is "24h" in any format.
+func unmarshalConfig(data []byte, format string, config *Config) error {
+	var value interface{}
+	switch strings.ToLower(format) {
+	case "yaml", "yml":
+		return unmarshalYAML(data, config)
+	case "json":
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+	case "toml":
+		table := make(map[string]interface{})
+		if _, err := toml.Decode(string(data), &table); err != nil {
+			return err
+		}
+		value = table
+	default:
+		return fmt.Errorf("format must be one of the yaml, json or toml, but got %q", format)
+	}
+
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return unmarshalYAML(data, config)
+}
+
+// unmarshalYAML unmarshals yaml onto config strictly. Empty levels, such as
+// "level:", are rejected, since yaml sets them to zero, which is info.
+//
+// The values yaml cannot decode are returned together as a *yaml.TypeError,
+// and config holds all the others.
+func unmarshalYAML(data []byte, config *Config) error {
+	var errs []string
+	if err := yaml.UnmarshalStrict(data, config); err != nil {
+		typeErr, ok := err.(*yaml.TypeError)
+		if !ok {
+			return err
+		}
+		errs = typeErr.Errors
+	}
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if value, ok := raw["level"]; ok && value == nil {
+		_, err := ParseLevel("")
+		errs = append(errs, err.Error())
+	}
+	modules, _ := raw["modules"].(map[interface{}]interface{})
+	var empty []string
+	for name, value := range modules {
+		if value == nil {
+			_, err := ParseLevel("")
+			empty = append(empty, fmt.Sprintf("modules.%v, error: %v", name, err))
+		}
+	}
+	sort.Strings(empty)
+	errs = append(errs, empty...)
+	if len(errs) > 0 {
+		return &yaml.TypeError{Errors: errs}
+	}
+	return nil
+}
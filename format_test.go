@@ -0,0 +1,115 @@
+// Copyright (c) 2018 souhup
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logx
+
+import (
+	"bytes"
+	"github.com/BurntSushi/toml"
+	"go.uber.org/multierr"
+	. "gopkg.in/check.v1"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+func (it *MySuite) TestGetLoggerFromReader(c *C) {
+	dir := c.MkDir()
+	configs := map[string]string{
+		"yaml": "level: warn\nlevel_key: ''\ntime_key: ''\ncaller_key: ''\n" +
+			"sinks:\n  - output: %v\n    rotate_interval: 1h\n",
+		"json": `{"level": "warn", "level_key": "", "time_key": "", "caller_key": "",
+			"sinks": [{"output": "%v", "rotate_interval": "1h"}]}`,
+		"TOML": "level = \"warn\"\nlevel_key = \"\"\ntime_key = \"\"\ncaller_key = \"\"\n" +
+			"[[sinks]]\noutput = \"%v\"\nrotate_interval = \"1h\"\n",
+	}
+	for format, config := range configs {
+		filename := filepath.Join(dir, format+".log")
+		config = strings.Replace(config, "%v", filename, 1)
+		logger, err := GetLoggerFromReader(strings.NewReader(config), format)
+		c.Assert(err, IsNil, Commentf("format %v", format))
+		c.Assert(logger.GetLevel(), Equals, WarnLevel)
+		logger.Info("hidden")
+		logger.Warn(format)
+		logger.Flush()
+
+		data, err := ioutil.ReadFile(filename)
+		c.Assert(err, IsNil)
+		c.Assert(string(data), Equals, `{"msg":"`+format+`"}`+"\n")
+	}
+}
+
+func (it *MySuite) TestGetLoggerFromReaderError(c *C) {
+	_, err := GetLoggerFromReader(strings.NewReader("level: warn"), "xml")
+	c.Assert(err, ErrorMatches, `unmarshal log configuration from reader, error: format must be one of the yaml, json or toml, but got "xml"`)
+
+	_, err = GetLoggerFromReader(strings.NewReader(`{"level": "warn", "levels": 1}`), "json")
+	c.Assert(err, ErrorMatches, `(?s)unmarshal log configuration from reader, error: .*field levels not found.*`)
+
+	_, err = GetLoggerFromReader(strings.NewReader(`max_size = "big"`), "toml")
+	c.Assert(err, ErrorMatches, `(?s)unmarshal log configuration from reader, error: .*cannot unmarshal.*`)
+}
+
+// TestConfigErrors reports the problems of a configuration together.
+func (it *MySuite) TestConfigErrors(c *C) {
+	content := "level: verbose\nlevels: warn\nencoding: xml\n"
+	_, err := parseConfig("errors", "yaml", []byte(content))
+	c.Assert(err, NotNil)
+	errs := multierr.Errors(err)
+	c.Assert(errs, HasLen, 2)
+	c.Assert(errs[0], ErrorMatches, `(?s)unmarshal log configuration errors, error: .*unknown level "verbose", .*field levels not found.*`)
+	c.Assert(errs[1], ErrorMatches, `validate log configuration errors, error: encoding must be one of the json or console, but got "xml"`)
+}
+
+func (it *MySuite) TestGetLoggerByExtension(c *C) {
+	dir := c.MkDir()
+	filename := filepath.Join(dir, "app.log")
+	path := filepath.Join(dir, "logs.json")
+	config := `{"level": "error", "file_name": "` + filename + `", "rotate_interval": "24h"}`
+	c.Assert(ioutil.WriteFile(path, []byte(config), 0644), IsNil)
+	logger, err := GetLogger(path)
+	c.Assert(err, IsNil)
+	c.Assert(logger.GetLevel(), Equals, ErrorLevel)
+	logger.Flush()
+
+	path = filepath.Join(dir, "logs.toml")
+	c.Assert(ioutil.WriteFile(path, []byte(config), 0644), IsNil)
+	_, err = GetLogger(path)
+	c.Assert(err, ErrorMatches, `(?s)unmarshal log configuration .*logs.toml, error: .*`)
+}
+
+// TestTOMLSection loads the configuration from a section of an application
+// configuration in toml.
+func (it *MySuite) TestTOMLSection(c *C) {
+	app := struct {
+		Name string
+		Log  map[string]interface{}
+	}{}
+	_, err := toml.Decode("name = \"app\"\n[log]\nlevel = \"info\"\nrotate_interval = \"1h\"\n", &app)
+	c.Assert(err, IsNil)
+
+	var section bytes.Buffer
+	c.Assert(toml.NewEncoder(&section).Encode(app.Log), IsNil)
+	config := DefaultConfig()
+	c.Assert(unmarshalConfig(section.Bytes(), "toml", config), IsNil)
+	c.Assert(config.Level, Equals, InfoLevel)
+	c.Assert(config.RotateInterval, Equals, time.Hour)
+}
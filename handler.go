@@ -0,0 +1,172 @@
+// Copyright (c) 2018 souhup
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// levelHandler is the http.Handler returned by LevelHandler.
+type levelHandler struct {
+	logger *Logger
+
+	mu       sync.Mutex
+	revert   *time.Timer
+	previous Level
+	revertAt time.Time
+}
+
+// levelPayload is the body of requests and responses of LevelHandler.
+type levelPayload struct {
+	Level    *Level `json:"level,omitempty"`
+	Duration string `json:"duration,omitempty"`
+	RevertAt string `json:"revert_at,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// LevelHandler returns an http.Handler which reports and changes the level
+// of logger. If logger is nil, it works on X at the time of each request.
+//
+// GET responds with the current level, such as {"level":"info"}.
+//
+// PUT or POST changes the level by a JSON body such as
+// {"level":"debug","duration":"10m"}, or by a form with the same fields.
+// If duration is given, the level reverts to the previous one after it.
+func LevelHandler(logger *Logger) http.Handler {
+	return &levelHandler{logger: logger}
+}
+
+func (it *levelHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := it.logger
+	if logger == nil {
+		logger = X
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPost:
+		level, duration, err := decodeLevelRequest(r)
+		if err != nil {
+			writeLevelPayload(w, http.StatusBadRequest, levelPayload{Error: err.Error()})
+			return
+		}
+		it.setLevel(logger, level, duration)
+	default:
+		w.Header().Set("Allow", "GET, PUT, POST")
+		writeLevelPayload(w, http.StatusMethodNotAllowed,
+			levelPayload{Error: fmt.Sprintf("method %v is not allowed", r.Method)})
+		return
+	}
+
+	level := logger.GetLevel()
+	payload := levelPayload{Level: &level}
+	it.mu.Lock()
+	if it.revert != nil {
+		payload.RevertAt = it.revertAt.Format(time.RFC3339)
+	}
+	it.mu.Unlock()
+	writeLevelPayload(w, http.StatusOK, payload)
+}
+
+// setLevel changes the level of logger, and reverts it after duration if
+// duration is positive. Changing the level again cancels a pending revert,
+// and a temporary change on top of another one reverts to the level before
+// both of them.
+func (it *levelHandler) setLevel(logger *Logger, level Level, duration time.Duration) {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+
+	previous := logger.GetLevel()
+	if it.revert != nil {
+		it.revert.Stop()
+		it.revert = nil
+		previous = it.previous
+	}
+	logger.SetLevel(level)
+	if duration <= 0 {
+		return
+	}
+
+	it.previous = previous
+	it.revertAt = time.Now().Add(duration)
+	var timer *time.Timer
+	timer = time.AfterFunc(duration, func() {
+		it.mu.Lock()
+		defer it.mu.Unlock()
+		if it.revert != timer {
+			return
+		}
+		logger.SetLevel(previous)
+		it.revert = nil
+	})
+	it.revert = timer
+}
+
+// decodeLevelRequest reads the level and the duration from a JSON body or a
+// form.
+func decodeLevelRequest(r *http.Request) (level Level, duration time.Duration, err error) {
+	var payload levelPayload
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if contentType == "application/json" {
+		if err = json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			err = fmt.Errorf("decode request body, error: %v", err)
+			return
+		}
+	} else {
+		if err = r.ParseForm(); err != nil {
+			err = fmt.Errorf("parse form, error: %v", err)
+			return
+		}
+		if text := r.Form.Get("level"); text != "" {
+			var l Level
+			if l, err = ParseLevel(text); err != nil {
+				return
+			}
+			payload.Level = &l
+		}
+		payload.Duration = r.Form.Get("duration")
+	}
+
+	if payload.Level == nil {
+		err = errors.New("level must be given")
+		return
+	}
+	level = *payload.Level
+	if payload.Duration != "" {
+		if duration, err = time.ParseDuration(payload.Duration); err != nil {
+			err = fmt.Errorf("parse duration %q, error: %v", payload.Duration, err)
+			return
+		}
+	}
+	return
+}
+
+func writeLevelPayload(w http.ResponseWriter, status int, payload levelPayload) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(payload)
+}
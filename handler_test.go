@@ -0,0 +1,83 @@
+// Copyright (c) 2018 souhup
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logx
+
+import (
+	. "gopkg.in/check.v1"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+)
+
+func (it *MySuite) TestLevelHandler(c *C) {
+	logger, err := GetLoggerByConf(&Config{MessageKey: "msg", Encoding: "json"})
+	c.Assert(err, IsNil)
+	handler := LevelHandler(logger)
+
+	res := serveLevel(handler, http.MethodGet, "", "")
+	c.Assert(res.Code, Equals, http.StatusOK)
+	c.Assert(res.Body.String(), Equals, `{"level":"info"}`+"\n")
+
+	res = serveLevel(handler, http.MethodPut, "application/json", `{"level":"debug"}`)
+	c.Assert(res.Code, Equals, http.StatusOK)
+	c.Assert(logger.GetLevel(), Equals, DebugLevel)
+
+	res = serveLevel(handler, http.MethodPost, "application/x-www-form-urlencoded", "level=WARN")
+	c.Assert(res.Code, Equals, http.StatusOK)
+	c.Assert(logger.GetLevel(), Equals, WarnLevel)
+
+	res = serveLevel(handler, http.MethodPut, "application/json", `{"level":"verbose"}`)
+	c.Assert(res.Code, Equals, http.StatusBadRequest)
+	c.Assert(logger.GetLevel(), Equals, WarnLevel)
+	res = serveLevel(handler, http.MethodPut, "application/json", `{"level":""}`)
+	c.Assert(res.Code, Equals, http.StatusBadRequest)
+	c.Assert(logger.GetLevel(), Equals, WarnLevel)
+
+	res = serveLevel(handler, http.MethodDelete, "", "")
+	c.Assert(res.Code, Equals, http.StatusMethodNotAllowed)
+}
+
+func (it *MySuite) TestLevelHandlerRevert(c *C) {
+	logger, err := GetLoggerByConf(&Config{MessageKey: "msg", Encoding: "json"})
+	c.Assert(err, IsNil)
+	handler := LevelHandler(logger)
+
+	res := serveLevel(handler, http.MethodPut, "application/json", `{"level":"debug","duration":"10ms"}`)
+	c.Assert(res.Code, Equals, http.StatusOK)
+	c.Assert(res.Body.String(), Matches, `\{"level":"debug","revert_at":".+"\}`+"\n")
+	c.Assert(logger.GetLevel(), Equals, DebugLevel)
+
+	for i := 0; i < 100 && logger.GetLevel() != InfoLevel; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.Assert(logger.GetLevel(), Equals, InfoLevel)
+}
+
+func serveLevel(handler http.Handler, method, contentType, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/log/level", strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	return res
+}
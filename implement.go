@@ -24,13 +24,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"go.uber.org/zap"
 )
 
 // X is a instance of Logger, and it will be initialized when logx is imported.
 var X *Logger
 
-const contextLogKey = "_logx"
-
 type method uint8
 
 const (
@@ -57,57 +56,77 @@ func (it *Logger) Flush() {
 
 // With adds entries and constructs a new Logger.
 // Note that the keys in key-value pairs should be strings.
-func (it *Logger) With(keysAndValues ...interface{}) (log *Logger) {
-	log = new(Logger)
-	log.sugar = it.sugar.With(keysAndValues...)
-	return
+func (it *Logger) With(keysAndValues ...interface{}) Log {
+	log := it.derive(it.sugar.With(keysAndValues...))
+	for i := 0; i < len(keysAndValues); i++ {
+		switch key := keysAndValues[i].(type) {
+		case Field:
+			log.keys = append(log.keys, key.Key)
+		case string:
+			log.keys = append(log.keys, key)
+			i++
+		default:
+			i++
+		}
+	}
+	return log
 }
 
 // Withc adds entries and constructs a new Logger, and uses fmt.Sprintf to store a templated message.
-func (it *Logger) Withf(key string, format string, params ...interface{}) (log *Logger) {
+func (it *Logger) Withf(key string, format string, params ...interface{}) Log {
+	log := it.derive(it.sugar.With(key, fmt.Sprintf(format, params...)))
+	log.keys = append(log.keys, key)
+	return log
+}
+
+// Named adds a segment to the name of the Logger and constructs a new Logger.
+// Segments are joined by periods, so X.Named("db").Named("pool") is named
+// db.pool. The name is written under NameKey, and the minimum level of the
+// new Logger is decided by Modules of Config.
+func (it *Logger) Named(name string) Log {
+	return it.derive(it.sugar.Named(name))
+}
+
+// derive constructs a Logger on top of sugar, which is derived from the
+// sugar of the Logger. The new Logger shares the level and the cores of the
+// Logger, and keeps the options such as the caller skip.
+func (it *Logger) derive(sugar *zap.SugaredLogger) (log *Logger) {
 	log = new(Logger)
-	log.sugar = it.sugar.With(key, fmt.Sprintf(format, params...))
+	log.zapLogger = sugar.Desugar()
+	log.sugar = sugar
+	log.level = it.level
+	log.root = it.root
+	log.callerSkip = it.callerSkip
+	// the keys are appended by the new Logger, not shared with it.
+	log.keys = it.keys[:len(it.keys):len(it.keys)]
 	return
 }
 
-//  same as With, but store in context
+// WithCallerSkip constructs a new Logger which reports the caller skip more
+// frames up. It is for wrappers of the Logger, so that the callers of the
+// wrappers are reported instead of the wrappers, see Base.
+func (it *Logger) WithCallerSkip(skip int) *Logger {
+	log := it.derive(it.zapLogger.WithOptions(zap.AddCallerSkip(skip)).Sugar())
+	log.callerSkip += skip
+	return log
+}
+
+// Withc is same as With, but stores the new Logger in ctx. If ctx holds a
+// Logger already, the new one is derived from it, and the one in ctx is left
+// unchanged, so that contexts derived from the same parent do not share
+// their entries.
 func (it *Logger) Withc(ctx context.Context, keysAndValues ...interface{}) context.Context {
-	if ctx == nil {
-		ctx = context.TODO()
-	}
-	value := ctx.Value(contextLogKey)
-	var log *Logger
-	switch value.(type) {
-	case *Logger:
-		log = value.(*Logger)
-		log.sugar = log.sugar.With(keysAndValues...)
-	default:
-		log = new(Logger)
-		log.sugar = it.sugar.With(keysAndValues...)
-	}
-	return context.WithValue(ctx, contextLogKey, log)
+	return NewContext(ctx, fromContext(ctx, it).With(keysAndValues...))
 }
 
-//  same as Withf, but store in context
+// Withcf is same as Withf, but stores the new Logger in ctx like Withc.
 func (it *Logger) Withcf(ctx context.Context, key string, format string, params ...interface{}) context.Context {
-	if ctx == nil {
-		ctx = context.TODO()
-	}
-	value := ctx.Value(contextLogKey)
-	var log *Logger
-	switch value.(type) {
-	case *Logger:
-		log = value.(*Logger)
-		log.sugar = log.sugar.With(key, fmt.Sprintf(format, params...))
-	default:
-		log = new(Logger)
-		log.sugar = it.sugar.With(key, fmt.Sprintf(format, params...))
-	}
-	return context.WithValue(ctx, contextLogKey, log)
+	return NewContext(ctx, fromContext(ctx, it).Withf(key, format, params...))
 }
 
 // Debug uses fmt.Sprint to construct and logs a message.
-// If value is struct, it will be converted to JSON.
+// Structs, maps, slices and marshalers are added as nested objects named by
+// their types, see Struct.
 func (it *Logger) Debug(v ...interface{}) {
 	generate(nil, it, Debug, "", v...)
 }
@@ -128,7 +147,8 @@ func (it *Logger) Debugcf(ctx context.Context, format string, params ...interfac
 }
 
 // Info uses fmt.Sprint to construct and log a message.
-// If value is struct, it will be converted to JSON.
+// Structs, maps, slices and marshalers are added as nested objects named by
+// their types, see Struct.
 func (it *Logger) Info(v ...interface{}) {
 	generate(nil, it, Info, "", v...)
 }
@@ -149,7 +169,8 @@ func (it *Logger) Infocf(ctx context.Context, format string, params ...interface
 }
 
 // Warn uses fmt.Sprint to construct and log a message.
-// If value is struct, it will be converted to JSON.
+// Structs, maps, slices and marshalers are added as nested objects named by
+// their types, see Struct.
 func (it *Logger) Warn(v ...interface{}) {
 	generate(nil, it, Warn, "", v...)
 }
@@ -170,7 +191,8 @@ func (it *Logger) Warncf(ctx context.Context, format string, params ...interface
 }
 
 // Error uses fmt.Sprint to construct and log a message.
-// If value is struct, it will be converted to JSON.
+// Structs, maps, slices and marshalers are added as nested objects named by
+// their types, see Struct.
 func (it *Logger) Error(v ...interface{}) {
 	generate(nil, it, Error, "", v...)
 }
@@ -187,11 +209,12 @@ func (it *Logger) Errorc(ctx context.Context, v ...interface{}) {
 
 // same as Errorf, but print with content in context
 func (it *Logger) Errorcf(ctx context.Context, format string, params ...interface{}) {
-	generate(ctx, it, Error, format, params)
+	generate(ctx, it, Error, format, params...)
 }
 
 // Fatal uses fmt.Sprint to construct and log a message.
-// If value is struct, it will be converted to JSON.
+// Structs, maps, slices and marshalers are added as nested objects named by
+// their types, see Struct.
 func (it *Logger) Fatal(v ...interface{}) {
 	generate(nil, it, Fatal, "", v...)
 }
@@ -212,7 +235,8 @@ func (it *Logger) Fatalcf(ctx context.Context, format string, params ...interfac
 }
 
 // Panic uses fmt.Sprint to construct and log a message.
-// If value is struct, it will be converted to JSON.
+// Structs, maps, slices and marshalers are added as nested objects named by
+// their types, see Struct.
 func (it *Logger) Panic(v ...interface{}) {
 	generate(nil, it, Panic, "", v...)
 }
@@ -235,43 +259,135 @@ func (it *Logger) Paniccf(ctx context.Context, format string, params ...interfac
 func generate(ctx context.Context, self *Logger, fun method, format interface{}, params ...interface{}) {
 
 	var msg string
+	var objects []Field
 	if len(format.(string)) > 0 {
 		msg = fmt.Sprintf(format.(string), params...)
 	} else {
-		for i, param := range params {
-			if i == 0 {
-				msg += fmt.Sprintf("%+v", param)
-			} else {
-				msg += fmt.Sprintf(" %+v", param)
+		for _, param := range params {
+			if field, ok := objectArg(param); ok {
+				objects = append(objects, field)
+				continue
+			}
+			if len(msg) > 0 {
+				msg += " "
 			}
+			msg += fmt.Sprintf("%+v", param)
+		}
+	}
+	ctxLog := fromContext(ctx, self)
+	var fields []interface{}
+	if len(objects) > 0 {
+		keyed, ok := ctxLog.(*Logger)
+		if !ok {
+			keyed = self
+		}
+		taken := keyed.takenKeys()
+		for _, field := range objects {
+			fields = append(fields, uniqueKey(field, fields, taken))
 		}
 	}
 	var basicLog *Logger
-	if ctx != nil {
-		value := ctx.Value(contextLogKey)
-		switch value.(type) {
-		case *Logger:
-			basicLog = value.(*Logger)
-		default:
-			basicLog = self
+	switch log := ctxLog.(type) {
+	case *Logger:
+		basicLog = log
+		if basicLog.callerSkip != self.callerSkip {
+			basicLog = basicLog.WithCallerSkip(self.callerSkip - basicLog.callerSkip)
 		}
-	} else {
-		basicLog = self
+	default:
+		// other implements of Log held by ctx log the message by themselves.
+		logTo(log, fun, msg, fields)
+		return
 	}
 
+	// fields are typed, so the sugar adds them as they are.
 	switch fun {
 	case Debug:
-		basicLog.sugar.Debug(msg)
+		basicLog.sugar.Debugw(msg, fields...)
 	case Info:
-		basicLog.sugar.Info(msg)
+		basicLog.sugar.Infow(msg, fields...)
 	case Warn:
-		basicLog.sugar.Warn(msg)
+		basicLog.sugar.Warnw(msg, fields...)
 	case Error:
-		basicLog.sugar.Error(msg)
+		basicLog.sugar.Errorw(msg, fields...)
 	case Fatal:
-		basicLog.sugar.Fatal(msg)
+		basicLog.sugar.Fatalw(msg, fields...)
 	case Panic:
-		basicLog.sugar.Panic(msg)
+		basicLog.sugar.Panicw(msg, fields...)
 	}
 	return
 }
+
+// uniqueKey suffixes the key of field by a number if the key is used by
+// fields or taken, such as map_2 for the second map.
+func uniqueKey(field Field, fields []interface{}, taken []string) Field {
+	used := func(key string) bool {
+		for _, other := range fields {
+			if other.(Field).Key == key {
+				return true
+			}
+		}
+		for _, other := range taken {
+			if other == key {
+				return true
+			}
+		}
+		return false
+	}
+	key := field.Key
+	for n := 2; used(key); n++ {
+		key = fmt.Sprintf("%v_%d", field.Key, n)
+	}
+	field.Key = key
+	return field
+}
+
+// takenKeys returns the keys the fields of an entry should not use, which
+// are the keys of the encoders and of the fields added by With.
+func (it *Logger) takenKeys() []string {
+	gen := it.root.current.Load().(*generation)
+	return append(gen.keys[:len(gen.keys):len(gen.keys)], it.keys...)
+}
+
+// logTo logs msg and fields by the method of log.
+func logTo(log Log, fun method, msg string, fields []interface{}) {
+	if len(fields) > 0 {
+		typed := make([]Field, len(fields))
+		for i, field := range fields {
+			typed[i] = field.(Field)
+		}
+		logFieldsTo(log, fun, msg, typed)
+		return
+	}
+	switch fun {
+	case Debug:
+		log.Debug(msg)
+	case Info:
+		log.Info(msg)
+	case Warn:
+		log.Warn(msg)
+	case Error:
+		log.Error(msg)
+	case Fatal:
+		log.Fatal(msg)
+	case Panic:
+		log.Panic(msg)
+	}
+}
+
+// logFieldsTo logs msg and fields by the Fields method of log.
+func logFieldsTo(log Log, fun method, msg string, fields []Field) {
+	switch fun {
+	case Debug:
+		log.DebugFields(msg, fields...)
+	case Info:
+		log.InfoFields(msg, fields...)
+	case Warn:
+		log.WarnFields(msg, fields...)
+	case Error:
+		log.ErrorFields(msg, fields...)
+	case Fatal:
+		log.FatalFields(msg, fields...)
+	case Panic:
+		log.PanicFields(msg, fields...)
+	}
+}
@@ -23,16 +23,29 @@ package logx
 import (
 	"context"
 	"go.uber.org/zap"
+	"sync"
+	"time"
 )
 
 // Config is struct about configuration file.
 type Config struct {
-	// Level is the minimum log level
-	// -1 is debug level,
-	// 0 is info level,
-	// 1 is warn level,
-	// 2 is error level
-	Level int8 `yaml:"level"`
+	// Level is the minimum log level, by name or by number
+	// debug or -1 is debug level,
+	// info or 0 is info level,
+	// warn or 1 is warn level,
+	// error or 2 is error level,
+	// dpanic or 3 is dpanic level,
+	// panic or 4 is panic level,
+	// fatal or 5 is fatal level.
+	// Names are case-insensitive.
+	Level Level `yaml:"level"`
+
+	// Modules are the minimum levels of named loggers, see Logger.Named.
+	// A logger inherits the level of its closest ancestor in the map, so
+	// with {db: warn, db.pool: debug} the logger named db.pool.conn logs at
+	// debug, db.cache at warn and others at Level. Modules may be lower than
+	// Level.
+	Modules map[string]Level `yaml:"modules"`
 
 	// keys used for each log entry. If any key is empty, that portion
 	// of the entry is omitted.
@@ -40,6 +53,7 @@ type Config struct {
 	LevelKey   string `yaml:"level_key"`   // key of level
 	TimeKey    string `yaml:"time_key"`    // key of time
 	CallerKey  string `yaml:"caller_key"`  // key of caller
+	NameKey    string `yaml:"name_key"`    // key of the name of logger
 
 	// encoding of log, just is json or console
 	Encoding string `yaml:"encoding"`
@@ -69,54 +83,175 @@ type Config struct {
 	// time.
 	LocalTime bool `yaml:"local_time"`
 
+	// RotateInterval is the period after which the log file gets rotated,
+	// such as 1h or 24h. Rotation happens when either MaxSize or
+	// RotateInterval is reached, whichever comes first. The default is not
+	// to rotate by time.
+	RotateInterval time.Duration `yaml:"rotate_interval"`
+
+	// RotateAlign determines if the periods of RotateInterval are aligned to
+	// the clock, counting from midnight, so that 1h rotates on the hour and
+	// 24h rotates at midnight. The default is to count from the time the log
+	// file is opened.
+	RotateAlign bool `yaml:"rotate_align"`
+
+	// BackupTimeFormat is the layout, as defined by the time package, of the
+	// timestamp in backup file names <name>-<timestamp><ext>. Backups rotated
+	// by RotateInterval are stamped with the start of their period, so
+	// 2006-01-02T15 names hourly backups like app-2026-10-17T13.log. The
+	// default is 2006-01-02T15-04-05.000.
+	BackupTimeFormat string `yaml:"backup_time_format"`
+
 	// Compress determines if the rotated log files should be compressed
 	// using gzip.
 	Compress bool `yaml:"compress"`
+
+	// Compression selects the algorithm used to compress rotated log files,
+	// just is gzip, zstd, none or a name registered by RegisterCompressor.
+	// It takes precedence over Compress, and the default is gzip if Compress
+	// is set and none otherwise.
+	Compression string `yaml:"compression"`
+
+	// CompressionLevel is the level passed to the compression algorithm.
+	// The default is the default level of the algorithm.
+	CompressionLevel int `yaml:"compression_level"`
+
+	// BufferSize is the number of entries queued for each output, which are
+	// written in the background, so that logging does not wait for slow
+	// outputs. The default 0 is to write synchronously.
+	BufferSize int `yaml:"buffer_size"`
+
+	// FlushInterval is how often the entries written in the background are
+	// flushed to the outputs. The default 0 is to flush whenever the queue
+	// gets empty. Logger.Flush always writes the queued entries.
+	FlushInterval time.Duration `yaml:"flush_interval"`
+
+	// Overflow is what to do when the queue of an output is full, just is
+	// block, drop_newest or drop_oldest. The default is block. Dropped
+	// entries are counted by Logger.Stats.
+	Overflow string `yaml:"overflow"`
+
+	// Sampling limits the entries of the same level and message logged in
+	// each tick, see Sampling. The default is to log all entries.
+	Sampling *Sampling `yaml:"sampling"`
+
+	// DedupWindow collapses consecutive entries of the same level, name,
+	// message and fields logged within the window, such as 10s. The first
+	// entry is written, followed by "last message repeated N times" once a
+	// different entry comes or Logger.Flush is called. The default 0 is not
+	// to collapse entries.
+	DedupWindow time.Duration `yaml:"dedup_window"`
+
+	// Redact conceals sensitive keys and values before entries are
+	// encoded, see Redact. The default is to write entries as they are.
+	Redact *Redact `yaml:"redact"`
+
+	// Sinks are the outputs of logs, each with its own destination, level
+	// and encoding. If any sink is given, Filename and the rotation above are
+	// ignored, otherwise logs are written to Filename, or stdout if it is
+	// empty.
+	Sinks []Sink `yaml:"sinks"`
+}
+
+// Sink is an output of logs.
+type Sink struct {
+	// Output is where logs are written to, just is stdout, stderr or the
+	// file to write logs to.
+	Output string `yaml:"output"`
+
+	// Level is the minimum log level of the sink. It only filters entries
+	// enabled by the level of the Logger, and the default is to write all
+	// of them.
+	Level *Level `yaml:"level"`
+
+	// encoding of the sink, just is json or console. The default is the
+	// encoding of Config.
+	Encoding string `yaml:"encoding"`
+
+	// Rotation of the file, ignored if Output is stdout or stderr.
+	Rotation `yaml:",inline"`
+}
+
+// Rotation is about rotating and retaining a log file. The fields are the
+// same as those of Config.
+type Rotation struct {
+	MaxSize          int           `yaml:"max_size"`
+	MaxAge           int           `yaml:"max_age"`
+	MaxBackups       int           `yaml:"max_backups"`
+	LocalTime        bool          `yaml:"local_time"`
+	RotateInterval   time.Duration `yaml:"rotate_interval"`
+	RotateAlign      bool          `yaml:"rotate_align"`
+	BackupTimeFormat string        `yaml:"backup_time_format"`
+	Compress         bool          `yaml:"compress"`
+	Compression      string        `yaml:"compression"`
+	CompressionLevel int           `yaml:"compression_level"`
 }
 
 // Log is a logger interface. It contains all API about logx.
+//
+// The loggers derived by With, Withf, WithFields and Named are Log too, so
+// that Log can be implemented by fakes and wrappers, see NopLogger and Base.
 type Log interface {
 	Show(interface{})
 
 	Flush()
-	With(...interface{}) *Logger
-	Withf(string, string, ...interface{}) *Logger
+	With(...interface{}) Log
+	Withf(string, string, ...interface{}) Log
 	Withc(context.Context, ...interface{}) context.Context
 	Withcf(context.Context, string, string, ...interface{}) context.Context
+	WithFields(...Field) Log
+	Named(string) Log
 
 	Debug(...interface{})
 	Debugf(string, ...interface{})
 	Debugc(context.Context, ...interface{})
 	Debugcf(context.Context, string, ...interface{})
+	DebugFields(string, ...Field)
 
 	Info(...interface{})
 	Infof(string, ...interface{})
 	Infoc(context.Context, ...interface{})
 	Infocf(context.Context, string, ...interface{})
+	InfoFields(string, ...Field)
 
 	Warn(...interface{})
 	Warnf(string, ...interface{})
 	Warnc(context.Context, ...interface{})
 	Warncf(context.Context, string, ...interface{})
+	WarnFields(string, ...Field)
 
 	Error(...interface{})
 	Errorf(string, ...interface{})
 	Errorc(context.Context, ...interface{})
 	Errorcf(context.Context, string, ...interface{})
+	ErrorFields(string, ...Field)
 
 	Fatal(...interface{})
 	Fatalf(string, ...interface{})
 	Fatalc(context.Context, ...interface{})
 	Fatalcf(context.Context, string, ...interface{})
+	FatalFields(string, ...Field)
 
 	Panic(...interface{})
 	Panicf(string, ...interface{})
 	Panicc(context.Context, ...interface{})
 	Paniccf(context.Context, string, ...interface{})
+	PanicFields(string, ...Field)
 }
 
 // Logger is the implement about Log.
 type Logger struct {
 	zapLogger *zap.Logger
 	sugar     *zap.SugaredLogger
+	level     zap.AtomicLevel
+	root      *coreRoot
+
+	// callerSkip is the caller skip added by WithCallerSkip.
+	callerSkip int
+
+	// keys are the keys of the fields added by With.
+	keys []string
+
+	// limited is the Logs derived by Every and Once, by their keys.
+	limited sync.Map
 }
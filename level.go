@@ -0,0 +1,117 @@
+// Copyright (c) 2018 souhup
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logx
+
+import (
+	"encoding/json"
+	"fmt"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/yaml.v2"
+	"strconv"
+	"strings"
+)
+
+// Level is a logging priority. Higher levels are more important.
+type Level int8
+
+const (
+	// DebugLevel logs are typically voluminous, and are usually disabled in
+	// production.
+	DebugLevel Level = iota - 1
+	// InfoLevel is the default logging priority.
+	InfoLevel
+	// WarnLevel logs are more important than Info, but don't need individual
+	// human review.
+	WarnLevel
+	// ErrorLevel logs are high-priority.
+	ErrorLevel
+	// DPanicLevel logs are particularly important errors.
+	DPanicLevel
+	// PanicLevel logs a message, then panics.
+	PanicLevel
+	// FatalLevel logs a message, then calls os.Exit(1).
+	FatalLevel
+)
+
+// String returns a lower-case name of the level, such as "debug".
+func (l Level) String() string {
+	return zapcore.Level(l).String()
+}
+
+// ParseLevel parses a level by its name, such as debug or WARN, or by its
+// number, such as -1. Empty text is unknown, though zap takes it as info.
+func ParseLevel(text string) (Level, error) {
+	if text != "" {
+		var level zapcore.Level
+		if err := level.UnmarshalText([]byte(strings.ToLower(text))); err == nil {
+			return Level(level), nil
+		}
+		if n, err := strconv.ParseInt(text, 10, 8); err == nil && n >= int64(DebugLevel) && n <= int64(FatalLevel) {
+			return Level(n), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown level %q, just is debug, info, warn, error, dpanic, panic, fatal or -1 to 5", text)
+}
+
+// MarshalText marshals the level to its name.
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText unmarshals a level parsed by ParseLevel.
+func (l *Level) UnmarshalText(text []byte) (err error) {
+	*l, err = ParseLevel(string(text))
+	return
+}
+
+// UnmarshalJSON unmarshals a level by its name or its number.
+func (l *Level) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		text = string(data)
+	}
+	return l.UnmarshalText([]byte(text))
+}
+
+// UnmarshalYAML unmarshals a level by its name or its number. An unknown
+// level is a *yaml.TypeError, so that yaml goes on and reports it together
+// with the other errors of the document.
+func (l *Level) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var text string
+	if err := unmarshal(&text); err != nil {
+		return err
+	}
+	if err := l.UnmarshalText([]byte(text)); err != nil {
+		return &yaml.TypeError{Errors: []string{err.Error()}}
+	}
+	return nil
+}
+
+// SetLevel changes the minimum level of the Logger at runtime. It affects
+// all loggers derived from it, and the loggers it is derived from.
+func (it *Logger) SetLevel(level Level) {
+	it.level.SetLevel(zapcore.Level(level))
+}
+
+// GetLevel returns the minimum level of the Logger.
+func (it *Logger) GetLevel() Level {
+	return Level(it.level.Level())
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2018 souhup
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logx
+
+import (
+	"encoding/json"
+	. "gopkg.in/check.v1"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+func (it *MySuite) TestParseLevel(c *C) {
+	for text, expected := range map[string]Level{
+		"debug": DebugLevel, "INFO": InfoLevel, "Warn": WarnLevel, "error": ErrorLevel,
+		"dpanic": DPanicLevel, "panic": PanicLevel, "FATAL": FatalLevel, "-1": DebugLevel, "2": ErrorLevel,
+	} {
+		level, err := ParseLevel(text)
+		c.Assert(err, IsNil)
+		c.Assert(level, Equals, expected)
+	}
+	for _, text := range []string{"verbose", "6", "-2", ""} {
+		_, err := ParseLevel(text)
+		c.Assert(err, ErrorMatches, `unknown level ".*", just is .*`)
+	}
+}
+
+func (it *MySuite) TestUnmarshalLevel(c *C) {
+	var config Config
+	c.Assert(yaml.Unmarshal([]byte("level: Warn"), &config), IsNil)
+	c.Assert(config.Level, Equals, WarnLevel)
+	c.Assert(yaml.Unmarshal([]byte("level: -1"), &config), IsNil)
+	c.Assert(config.Level, Equals, DebugLevel)
+
+	var level Level
+	c.Assert(json.Unmarshal([]byte(`"error"`), &level), IsNil)
+	c.Assert(level, Equals, ErrorLevel)
+	c.Assert(json.Unmarshal([]byte(`1`), &level), IsNil)
+	c.Assert(level, Equals, WarnLevel)
+}
+
+func (it *MySuite) TestGetLoggerUnknownLevel(c *C) {
+	path := filepath.Join(c.MkDir(), "logs.yml")
+	c.Assert(ioutil.WriteFile(path, []byte("level: verbose\nencoding: json\n"), 0644), IsNil)
+	_, err := GetLogger(path)
+	c.Assert(err, ErrorMatches, `(?s)unmarshal log configuration .*, error: .*unknown level "verbose", .*`)
+}
+
+func (it *MySuite) TestEmptyLevel(c *C) {
+	for _, t := range []struct {
+		format, content string
+	}{
+		{"yaml", "level: ''\n"},
+		{"yaml", "level:\n"},
+		{"json", `{"level": ""}`},
+		{"json", `{"level": null}`},
+		{"yaml", "modules:\n  db:\n"},
+	} {
+		_, err := parseConfig("empty", t.format, []byte(t.content))
+		c.Assert(err, ErrorMatches, `(?s)unmarshal log configuration empty, error: .*unknown level "", just is .*`, Commentf("%v", t.content))
+	}
+
+	defer os.Unsetenv("LOGX_LEVEL")
+	os.Setenv("LOGX_LEVEL", "")
+	config := DefaultConfig()
+	c.Assert(config.LoadEnv("LOGX"), ErrorMatches, `(?s)LOGX_LEVEL, error: .*unknown level "", just is .*`)
+}
@@ -0,0 +1,206 @@
+// Copyright (c) 2018 souhup
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logx
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Every returns a Log which logs at most once per d at the site calling
+// Every, such as X.Every(time.Minute).Warn("disk is almost full"). The
+// number of entries suppressed before an allowed one is added to it as the
+// field suppressed.
+func (it *Logger) Every(d time.Duration) Log {
+	pc, _, _, _ := runtime.Caller(1)
+	return it.limitOnce(everyKey{pc: pc, d: d}, 1, d)
+}
+
+// Limit returns a Log which logs at most n entries per period for the key,
+// such as X.Limit("db-timeout", 10, time.Second).Error(err). Entries of the
+// same key share the limit, which is set by the first call with the key.
+// The number of entries suppressed before an allowed one is added to it as
+// the field suppressed. The limit of a key idle for longer than per is
+// dropped, so keys may be per user or per request.
+//
+// Each call derives a new Log, so the Log of a fixed key is better stored
+// and reused, such as var dbTimeouts = X.Limit("db-timeout", 10, time.Second).
+func (it *Logger) Limit(key string, n int, per time.Duration) Log {
+	return it.limit(limitKey(key), n, per)
+}
+
+// Once returns a Log which logs only the first entry for the key. The keys
+// of Once and Limit are apart. The keys of Once are kept as long as the
+// Logger, so they should be few, such as one per deprecated API rather than
+// one per user.
+func (it *Logger) Once(key string) Log {
+	return it.limitOnce(onceKey(key), 1, 0)
+}
+
+// everyKey, limitKey and onceKey are the keys of limiters.
+type (
+	everyKey struct {
+		pc uintptr
+		d  time.Duration
+	}
+	limitKey string
+	onceKey  string
+)
+
+// limiterSweepInterval is how often idle limiters are dropped at most.
+const limiterSweepInterval = time.Minute
+
+// limit derives a Logger of which entries are limited by the limiter of key,
+// see limiterOf.
+func (it *Logger) limit(key interface{}, n int, per time.Duration) Log {
+	root := it.root
+	wrap := zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &limitCore{Core: core, root: root, key: key, n: n, per: per}
+	})
+	return it.derive(it.zapLogger.WithOptions(wrap).Sugar())
+}
+
+// limitOnce is limit, but derives the Log of key only once per Logger. It is
+// for the keys bounded by the code, such as the sites calling Every.
+func (it *Logger) limitOnce(key interface{}, n int, per time.Duration) Log {
+	if log, ok := it.limited.Load(key); ok {
+		return log.(Log)
+	}
+	log, _ := it.limited.LoadOrStore(key, it.limit(key, n, per))
+	return log.(Log)
+}
+
+// limiterOf returns the limiter of key, which is created with n tokens
+// refilled per period if absent. The tokens are never refilled if period is
+// 0. Creating a limiter drops the idle ones now and then.
+func (it *coreRoot) limiterOf(key interface{}, n int, per time.Duration) *limiter {
+	if value, ok := it.limiters.Load(key); ok {
+		return value.(*limiter)
+	}
+	value, loaded := it.limiters.LoadOrStore(key, newLimiter(n, per))
+	if !loaded {
+		now := time.Now()
+		sweptAt := atomic.LoadInt64(&it.limitersSweptAt)
+		if now.UnixNano()-sweptAt >= int64(limiterSweepInterval) &&
+			atomic.CompareAndSwapInt64(&it.limitersSweptAt, sweptAt, now.UnixNano()) {
+			it.sweepLimiters(now)
+		}
+	}
+	return value.(*limiter)
+}
+
+// sweepLimiters drops the limiters idle at now, which are the same as new
+// ones.
+func (it *coreRoot) sweepLimiters(now time.Time) {
+	it.limiters.Range(func(key, value interface{}) bool {
+		if value.(*limiter).idle(now) {
+			it.limiters.Delete(key)
+		}
+		return true
+	})
+}
+
+// limiter is a token bucket.
+type limiter struct {
+	mu         sync.Mutex
+	capacity   float64
+	per        time.Duration // the period to refill all tokens
+	perToken   time.Duration // the period to refill a token
+	tokens     float64
+	last       time.Time
+	suppressed uint64
+}
+
+func newLimiter(n int, per time.Duration) *limiter {
+	it := &limiter{capacity: float64(n), per: per, tokens: float64(n), last: time.Now()}
+	if n > 0 && per > 0 {
+		it.perToken = per / time.Duration(n)
+	}
+	return it
+}
+
+// allow takes a token at now. If there is none, it counts a suppressed
+// entry, otherwise it returns the number of suppressed entries since the
+// last allowed one.
+func (it *limiter) allow(now time.Time) (ok bool, suppressed uint64) {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+
+	if it.perToken > 0 && now.After(it.last) {
+		it.tokens += float64(now.Sub(it.last)) / float64(it.perToken)
+		if it.tokens > it.capacity {
+			it.tokens = it.capacity
+		}
+	}
+	if now.After(it.last) {
+		it.last = now
+	}
+	if it.tokens < 1 {
+		it.suppressed++
+		return false, 0
+	}
+	it.tokens--
+	suppressed, it.suppressed = it.suppressed, 0
+	return true, suppressed
+}
+
+// idle reports whether the limiter has refilled all tokens and has not been
+// used since, so it can be dropped. Limiters never refilled are never idle.
+func (it *limiter) idle(now time.Time) bool {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+	return it.perToken > 0 && it.suppressed == 0 && now.Sub(it.last) >= it.per
+}
+
+// limitCore is a zapcore.Core which drops the entries not allowed by its
+// limiter. The limiter is looked up by its key for each entry, since idle
+// limiters are dropped.
+type limitCore struct {
+	zapcore.Core
+	root *coreRoot
+	key  interface{}
+	n    int
+	per  time.Duration
+}
+
+func (it *limitCore) With(fields []zapcore.Field) zapcore.Core {
+	return &limitCore{Core: it.Core.With(fields), root: it.root, key: it.key, n: it.n, per: it.per}
+}
+
+func (it *limitCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	// the entries disabled anyway do not take tokens.
+	if !it.root.enabledFor(entry.LoggerName, entry.Level) {
+		return ce
+	}
+	ok, suppressed := it.root.limiterOf(it.key, it.n, it.per).allow(entry.Time)
+	if !ok {
+		atomic.AddUint64(&it.root.stats.suppressed, 1)
+		return ce
+	}
+	if suppressed > 0 {
+		return it.Core.With([]zapcore.Field{zap.Uint64("suppressed", suppressed)}).Check(entry, ce)
+	}
+	return it.Core.Check(entry, ce)
+}
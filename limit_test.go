@@ -0,0 +1,116 @@
+// Copyright (c) 2018 souhup
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logx
+
+import (
+	"fmt"
+	. "gopkg.in/check.v1"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+func (it *MySuite) TestLimit(c *C) {
+	filename := filepath.Join(c.MkDir(), "limit.log")
+	logger, err := GetLoggerByConf(&Config{Level: DebugLevel, MessageKey: "msg", Encoding: "json", Filename: filename})
+	c.Assert(err, IsNil)
+
+	for i := 0; i < 5; i++ {
+		logger.Limit("db-timeout", 2, 40*time.Millisecond).With("i", i).Error("timeout")
+	}
+	// the limit is shared by the key, and entries disabled take no tokens.
+	logger.Limit("db-timeout", 100, time.Hour).Debug("suppressed")
+	logger.SetLevel(InfoLevel)
+	logger.Limit("db-timeout", 100, time.Hour).Debug("hidden")
+	time.Sleep(50 * time.Millisecond)
+	logger.Limit("db-timeout", 2, 40*time.Millisecond).Error("timeout")
+	logger.Flush()
+
+	data, err := ioutil.ReadFile(filename)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Split(strings.TrimSpace(string(data)), "\n"), DeepEquals, []string{
+		`{"msg":"timeout","i":0}`,
+		`{"msg":"timeout","i":1}`,
+		`{"msg":"timeout","suppressed":4}`,
+	})
+	c.Assert(logger.Stats().Suppressed, Equals, uint64(4))
+}
+
+func (it *MySuite) TestEveryAndOnce(c *C) {
+	filename := filepath.Join(c.MkDir(), "every.log")
+	logger, err := GetLoggerByConf(&Config{MessageKey: "msg", Encoding: "json", Filename: filename})
+	c.Assert(err, IsNil)
+
+	// each site calling Every has its own limit.
+	for i := 0; i < 3; i++ {
+		logger.Every(time.Hour).Warn("first site")
+		logger.Every(time.Hour).Warn("second site")
+		logger.Once("start").Info("once")
+		logger.Once("start").Info("once again")
+	}
+	logger.Flush()
+
+	data, err := ioutil.ReadFile(filename)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Split(strings.TrimSpace(string(data)), "\n"), DeepEquals, []string{
+		`{"msg":"first site"}`,
+		`{"msg":"second site"}`,
+		`{"msg":"once"}`,
+	})
+	c.Assert(logger.Stats().Suppressed, Equals, uint64(9))
+
+	// the Logs of Every and Once are derived once per key.
+	c.Assert(logger.Once("start"), Equals, logger.Once("start"))
+	c.Assert(logger.Once("stop"), Not(Equals), logger.Once("start"))
+}
+
+func (it *MySuite) TestLimitIdle(c *C) {
+	logger, err := GetLoggerByConf(&Config{MessageKey: "msg", Encoding: "json", Filename: filepath.Join(c.MkDir(), "idle.log")})
+	c.Assert(err, IsNil)
+	count := func() (n int) {
+		logger.root.limiters.Range(func(key, value interface{}) bool {
+			n++
+			return true
+		})
+		return
+	}
+
+	for i := 0; i < 10; i++ {
+		logger.Limit(fmt.Sprint("user-", i), 1, time.Second).Info("login")
+	}
+	suppressed := logger.Limit("user-0", 1, time.Second)
+	suppressed.Info("login")
+	logger.Once("start").Info("once")
+	c.Assert(count(), Equals, 11)
+
+	// idle limiters are dropped, but not those with suppressed entries or of
+	// Once.
+	logger.root.sweepLimiters(time.Now().Add(time.Minute))
+	c.Assert(count(), Equals, 2)
+	logger.Once("start").Info("once again")
+	c.Assert(logger.Stats().Suppressed, Equals, uint64(2))
+
+	// the Log of a dropped limiter uses a new one.
+	logger.root.limiters.Delete(limitKey("user-0"))
+	suppressed.Info("login")
+	c.Assert(logger.Stats().Suppressed, Equals, uint64(2))
+}
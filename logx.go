@@ -22,7 +22,9 @@ package logx
 
 import (
 	"fmt"
+	"go.uber.org/multierr"
 	"gopkg.in/yaml.v2"
+	"io"
 	"io/ioutil"
 	"os"
 )
@@ -31,15 +33,24 @@ import (
 //
 // The default format of log is "<time> <level> <caller> <message>".
 func init() {
-	conf := Config{
+	X, _ = GetLoggerByConf(DefaultConfig())
+}
+
+// DefaultConfig returns the configuration of X when logx is imported.
+//
+// Configurations are layered, and a later layer overrides the fields set by
+// an earlier one: first DefaultConfig, then the configuration file if any,
+// then the environment variables, see Config.LoadEnv.
+func DefaultConfig() *Config {
+	return &Config{
 		MessageKey: "msg",
 		LevelKey:   "level",
 		TimeKey:    "time",
 		Encoding:   "json",
 		CallerKey:  "caller",
-		Level:      -1,
+		NameKey:    "logger",
+		Level:      DebugLevel,
 	}
-	X, _ = GetLoggerByConf(&conf)
 }
 
 // Init is a high-level wrapper that takes a URL, open specified configuration file,
@@ -55,6 +66,9 @@ func Init(path string) (err error) {
 
 // Init is a high-level wrapper that takes a URL, open specified configuration file,
 // and generate a Logger.
+//
+// The format of the file is detected by its extension: .json is json, .toml
+// is toml, and others are yaml.
 func GetLogger(path string) (logger *Logger, err error) {
 	file, err := ioutil.ReadFile(path)
 	if err != nil {
@@ -63,12 +77,77 @@ func GetLogger(path string) (logger *Logger, err error) {
 		return
 	}
 
-	var config Config
-	err = yaml.Unmarshal(file, &config)
+	config, err := parseConfig(path, formatOf(path), file)
 	if err != nil {
-		err = fmt.Errorf("unmarshal log configuration %v, error: %v", path, err)
 		fmt.Fprintln(os.Stderr, err.Error())
 		return
 	}
-	return GetLoggerByConf(&config)
+	return GetLoggerByConf(config)
+}
+
+// GetLoggerFromReader is same as GetLogger, but it reads the configuration
+// from r in the format, which just is yaml, json or toml.
+func GetLoggerFromReader(r io.Reader, format string) (logger *Logger, err error) {
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		err = fmt.Errorf("read log configuration from reader, error: %v", err)
+		fmt.Fprintln(os.Stderr, err.Error())
+		return
+	}
+
+	config, err := parseConfig("from reader", format, content)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return
+	}
+	return GetLoggerByConf(config)
+}
+
+// InitEnv is same as Init, but it reads the configuration from the
+// environment variables only, see GetLoggerFromEnv.
+func InitEnv() (err error) {
+	logger, err := GetLoggerFromEnv()
+	if err != nil {
+		return
+	}
+	X = logger
+	return
+}
+
+// GetLoggerFromEnv generates a Logger by DefaultConfig overridden by the
+// environment variables prefixed by EnvPrefix, without any configuration file.
+func GetLoggerFromEnv() (logger *Logger, err error) {
+	config := DefaultConfig()
+	if err = config.LoadEnv(EnvPrefix); err != nil {
+		err = fmt.Errorf("load log configuration from environment, error: %v", err)
+		fmt.Fprintln(os.Stderr, err.Error())
+		return
+	}
+	return GetLoggerByConf(config)
+}
+
+// parseConfig parses the content of the configuration in the format on top of
+// DefaultConfig, and then applies the environment variables. The source is the
+// path of the configuration, and it is only used in errors.
+func parseConfig(source, format string, content []byte) (*Config, error) {
+	config := DefaultConfig()
+	var errs error
+	if err := unmarshalConfig(content, format, config); err != nil {
+		// values yaml cannot decode are reported together with the problems
+		// of the rest, other errors leave nothing to validate.
+		if _, ok := err.(*yaml.TypeError); !ok {
+			return nil, fmt.Errorf("unmarshal log configuration %v, error: %v", source, err)
+		}
+		errs = fmt.Errorf("unmarshal log configuration %v, error: %v", source, err)
+	}
+	if err := config.LoadEnv(EnvPrefix); err != nil {
+		errs = multierr.Append(errs, fmt.Errorf("load log configuration from environment, error: %v", err))
+	}
+	if err := config.Validate(); err != nil {
+		errs = multierr.Append(errs, fmt.Errorf("validate log configuration %v, error: %v", source, err))
+	}
+	if errs != nil {
+		return nil, errs
+	}
+	return config, nil
 }
@@ -22,9 +22,17 @@ package logx
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"go.uber.org/zap/zapcore"
 	. "gopkg.in/check.v1"
+	"io/ioutil"
+	"path/filepath"
 	"reflect"
+	"regexp"
 	"runtime"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -47,6 +55,16 @@ func (it *MySuite) SetUpTest(c *C) {
 func (it *MySuite) TearDownTest(c *C) {
 }
 
+// callersBelow returns a function naming the line n lines below the line
+// calling callersBelow, as written by the caller encoder, so that tests do
+// not depend on the name of the directory of the repository.
+func callersBelow() func(n int) string {
+	_, file, line, _ := runtime.Caller(1)
+	return func(n int) string {
+		return zapcore.NewEntryCaller(0, file, line+n, true).TrimmedPath()
+	}
+}
+
 func (it *MySuite) TestGetLogger(c *C) {
 	logger, err := GetLogger("./config/logs.yml")
 	c.Assert(err, IsNil)
@@ -67,6 +85,49 @@ func (it *MySuite) TestGetLoggerByConf(c *C) {
 	logger.Info("test GetLogger")
 }
 
+func (it *MySuite) TestSinks(c *C) {
+	errorLevel := ErrorLevel
+	filename := filepath.Join(c.MkDir(), "error.log")
+	conf := Config{
+		MessageKey: "msg",
+		LevelKey:   "level",
+		Encoding:   "console",
+		Level:      -1,
+		Sinks: []Sink{
+			{Output: "stdout"},
+			{Output: filename, Level: &errorLevel, Encoding: "json"},
+		},
+	}
+	logger, err := GetLoggerByConf(&conf)
+	c.Assert(err, IsNil)
+	logger.Info("test Sinks")
+	logger.Error("test Sinks")
+	logger.Flush()
+
+	data, err := ioutil.ReadFile(filename)
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, `{"level":"ERROR","msg":"test Sinks"}`+"\n")
+}
+
+func (it *MySuite) TestSetLevel(c *C) {
+	filename := filepath.Join(c.MkDir(), "test.log")
+	conf := Config{MessageKey: "msg", Encoding: "json", Filename: filename}
+	logger, err := GetLoggerByConf(&conf)
+	c.Assert(err, IsNil)
+	derived := logger.With("a", 1).(*Logger)
+	c.Assert(derived.GetLevel(), Equals, InfoLevel)
+
+	logger.Debug("hidden")
+	derived.SetLevel(DebugLevel)
+	c.Assert(logger.GetLevel(), Equals, DebugLevel)
+	logger.Debug("shown")
+	logger.Flush()
+
+	data, err := ioutil.ReadFile(filename)
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, `{"msg":"shown"}`+"\n")
+}
+
 func (it *MySuite) TestWith(c *C) {
 	X.With("a", "1", "b", 2).
 		With("c", 3).
@@ -88,6 +149,137 @@ func (it *MySuite) TestWithcf(c *C) {
 	X.Debugc(ctx, "test Withcf")
 }
 
+func (it *MySuite) TestWithcKeepsParent(c *C) {
+	filename := filepath.Join(c.MkDir(), "withc.log")
+	logger, err := GetLoggerByConf(&Config{MessageKey: "msg", Encoding: "json", Filename: filename})
+	c.Assert(err, IsNil)
+
+	parent := logger.Withc(context.Background(), "request", "r1")
+	first := logger.Withc(parent, "step", 1)
+	second := logger.Withcf(parent, "step", "%v", 2)
+	logger.Infoc(first, "first")
+	logger.Infoc(second, "second")
+	logger.Infoc(parent, "parent")
+	logger.Flush()
+
+	data, err := ioutil.ReadFile(filename)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Split(strings.TrimSpace(string(data)), "\n"), DeepEquals, []string{
+		`{"msg":"first","request":"r1","step":1}`,
+		`{"msg":"second","request":"r1","step":"2"}`,
+		`{"msg":"parent","request":"r1"}`,
+	})
+}
+
+// TestWithcConcurrent derives contexts from a shared parent in many
+// goroutines, and it is meant to be run with -race too.
+func (it *MySuite) TestWithcConcurrent(c *C) {
+	filename := filepath.Join(c.MkDir(), "concurrent.log")
+	logger, err := GetLoggerByConf(&Config{MessageKey: "msg", Encoding: "json", Filename: filename})
+	c.Assert(err, IsNil)
+
+	const workers = 32
+	parent := logger.Withc(context.Background(), "request", "r1")
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ctx := logger.Withc(parent, "worker", i)
+			ctx = logger.Withcf(ctx, "step", "%d-%d", i, 1)
+			logger.Infoc(ctx, "work")
+		}(i)
+	}
+	wg.Wait()
+	logger.Infoc(parent, "done")
+	logger.Flush()
+
+	data, err := ioutil.ReadFile(filename)
+	c.Assert(err, IsNil)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	c.Assert(lines, HasLen, workers+1)
+	seen := make(map[string]bool)
+	for _, line := range lines[:workers] {
+		var entry struct {
+			Request string
+			Worker  int
+			Step    string
+		}
+		c.Assert(json.Unmarshal([]byte(line), &entry), IsNil)
+		c.Assert(entry.Request, Equals, "r1")
+		c.Assert(entry.Step, Equals, fmt.Sprintf("%d-1", entry.Worker))
+		c.Assert(strings.Count(line, `"worker"`), Equals, 1)
+		seen[entry.Step] = true
+	}
+	c.Assert(seen, HasLen, workers)
+	c.Assert(lines[workers], Equals, `{"msg":"done","request":"r1"}`)
+}
+
+// TestDerivedLoggers calls every method of Log on the base Logger and on the
+// loggers derived from it, which share its level, keys and outputs.
+func (it *MySuite) TestDerivedLoggers(c *C) {
+	filename := filepath.Join(c.MkDir(), "derived.log")
+	logger, err := GetLoggerByConf(&Config{MessageKey: "msg", CallerKey: "caller", Encoding: "json", Filename: filename})
+	c.Assert(err, IsNil)
+	logger.SetLevel(DebugLevel)
+	// the calls below are all in this file, at any line.
+	file := regexp.QuoteMeta(strings.Split(callersBelow()(0), ":")[0])
+
+	loggers := []struct {
+		name   string
+		logger Log
+	}{
+		{"base", logger},
+		{"With", logger.With("derived", 1)},
+		{"Withf", logger.Withf("derived", "%v", 1)},
+		{"WithFields", logger.WithFields(Int("derived", 1))},
+		{"Named", logger.Named("derived")},
+		{"Withc", FromContext(logger.Withc(context.Background(), "derived", 1))},
+		{"Withcf", FromContext(logger.Withcf(nil, "derived", "%v", 1))},
+	}
+	written := 0
+	for _, test := range loggers {
+		log := test.logger
+		ctx := log.Withc(context.Background(), "ctx", 1)
+		calls := []func(){
+			func() { log.Debug("m") }, func() { log.Debugf("%v", "m") },
+			func() { log.Debugc(ctx, "m") }, func() { log.Debugcf(ctx, "%v", "m") },
+			func() { log.DebugFields("m") },
+			func() { log.Info("m") }, func() { log.Infof("%v", "m") },
+			func() { log.Infoc(ctx, "m") }, func() { log.Infocf(ctx, "%v", "m") },
+			func() { log.InfoFields("m") },
+			func() { log.Warn("m") }, func() { log.Warnf("%v", "m") },
+			func() { log.Warnc(ctx, "m") }, func() { log.Warncf(ctx, "%v", "m") },
+			func() { log.WarnFields("m") },
+			func() { log.Error("m") }, func() { log.Errorf("%v", "m") },
+			func() { log.Errorc(ctx, "m") }, func() { log.Errorcf(ctx, "%v", "m") },
+			func() { log.ErrorFields("m") },
+			func() { log.Panic("m") }, func() { log.Panicf("%v", "m") },
+			func() { log.Panicc(ctx, "m") }, func() { log.Paniccf(ctx, "%v", "m") },
+			func() { log.PanicFields("m") },
+			func() { log.With("with", 1).Info("m") }, func() { log.Withf("with", "%v", 1).Info("m") },
+			func() { log.WithFields(Int("with", 1)).Info("m") },
+			func() { log.Infoc(log.Withcf(ctx, "with", "%v", 1), "m") },
+		}
+		for _, call := range calls {
+			func() {
+				defer func() { recover() }()
+				call()
+			}()
+		}
+		log.Flush()
+
+		data, err := ioutil.ReadFile(filename)
+		c.Assert(err, IsNil)
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")[written:]
+		c.Assert(lines, HasLen, len(calls), Commentf("logger %v", test.name))
+		for _, line := range lines {
+			c.Assert(line, Matches, `\{"caller":"`+file+`:\d+","msg":"m".*`, Commentf("logger %v", test.name))
+		}
+		written += len(lines)
+	}
+}
+
 func (it *MySuite) TestFlush(c *C) {
 	defer X.Flush()
 	X.Debug("test Flush")
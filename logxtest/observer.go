@@ -0,0 +1,203 @@
+// Copyright (c) 2018 souhup
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package logxtest provides loggers for testing the code using logx.
+package logxtest
+
+import (
+	"fmt"
+	"github.com/souhup/logx"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Reporter is the part of testing.T reporting errors, and *check.C of
+// gopkg.in/check.v1 is a Reporter too.
+type Reporter interface {
+	Errorf(format string, args ...interface{})
+}
+
+// Entry is a recorded log entry.
+type Entry struct {
+	Level   logx.Level
+	Time    time.Time
+	Name    string // name of the logger, see Logger.Named
+	Caller  string // file:line of the caller
+	Message string
+	Fields  map[string]interface{}
+}
+
+// String formats the entry like the console encoder.
+func (it Entry) String() string {
+	return fmt.Sprintf("%v\t%v\t%v\t%v", it.Level, it.Name, it.Message, it.Fields)
+}
+
+// Recorder records the entries of an observed Logger. The filters of it
+// return new Recorders on the same entries, which see the entries logged
+// later too.
+type Recorder struct {
+	logs    *observer.ObservedLogs
+	filters []func(Entry) bool
+}
+
+// NewObserved returns a Logger at the level, which records entries in
+// memory instead of writing them, and the Recorder of the entries.
+func NewObserved(level logx.Level) (*logx.Logger, *Recorder) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	return logx.GetLoggerByCore(core, level), &Recorder{logs: logs}
+}
+
+// All returns the recorded entries passing all filters, in the order they
+// are logged.
+func (it *Recorder) All() []Entry {
+	var entries []Entry
+	for _, logged := range it.logs.All() {
+		entry := Entry{
+			Level:   logx.Level(logged.Level),
+			Time:    logged.Time,
+			Name:    logged.LoggerName,
+			Message: logged.Message,
+			Fields:  logged.ContextMap(),
+		}
+		if logged.Caller.Defined {
+			entry.Caller = logged.Caller.TrimmedPath()
+		}
+		if it.match(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// Len returns the number of the recorded entries passing all filters.
+func (it *Recorder) Len() int {
+	return len(it.All())
+}
+
+// Filter returns a Recorder of the entries passing match too.
+func (it *Recorder) Filter(match func(Entry) bool) *Recorder {
+	filters := make([]func(Entry) bool, 0, len(it.filters)+1)
+	filters = append(filters, it.filters...)
+	filters = append(filters, match)
+	return &Recorder{logs: it.logs, filters: filters}
+}
+
+// FilterLevel returns a Recorder of the entries at the level.
+func (it *Recorder) FilterLevel(level logx.Level) *Recorder {
+	return it.Filter(func(entry Entry) bool { return entry.Level == level })
+}
+
+// FilterMinLevel returns a Recorder of the entries at or above the level.
+func (it *Recorder) FilterMinLevel(level logx.Level) *Recorder {
+	return it.Filter(func(entry Entry) bool { return entry.Level >= level })
+}
+
+// FilterMessage returns a Recorder of the entries of which the message
+// contains substr.
+func (it *Recorder) FilterMessage(substr string) *Recorder {
+	return it.Filter(func(entry Entry) bool { return strings.Contains(entry.Message, substr) })
+}
+
+// FilterName returns a Recorder of the entries logged by the logger named
+// name, see Logger.Named.
+func (it *Recorder) FilterName(name string) *Recorder {
+	return it.Filter(func(entry Entry) bool { return entry.Name == name })
+}
+
+// FilterField returns a Recorder of the entries with the field key of the
+// value. The value is compared as it is encoded, so 7 matches the field of
+// an int64 7 too.
+func (it *Recorder) FilterField(key string, value interface{}) *Recorder {
+	expected := encodeField(zap.Any(key, value))
+	return it.Filter(func(entry Entry) bool {
+		actual, ok := entry.Fields[key]
+		return ok && reflect.DeepEqual(actual, expected)
+	})
+}
+
+// filterFields returns a Recorder of the entries with the fields of the
+// key-value pairs.
+func (it *Recorder) filterFields(keysAndValues []interface{}) *Recorder {
+	recorder := it
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		recorder = recorder.FilterField(fmt.Sprint(keysAndValues[i]), keysAndValues[i+1])
+	}
+	return recorder
+}
+
+// AssertLogged reports an error to t unless an entry at the level, of which
+// the message contains substr and with the fields of the key-value pairs,
+// passes the filters of the Recorder.
+func (it *Recorder) AssertLogged(t Reporter, level logx.Level, substr string, keysAndValues ...interface{}) bool {
+	if helper, ok := t.(interface{ Helper() }); ok {
+		helper.Helper()
+	}
+	if it.FilterLevel(level).FilterMessage(substr).filterFields(keysAndValues).Len() > 0 {
+		return true
+	}
+	t.Errorf("no entry at %v with message %q and fields %v is logged, but got:\n%v",
+		level, substr, keysAndValues, it.dump())
+	return false
+}
+
+// AssertNotLogged reports an error to t if an entry at or above the level
+// passes the filters of the Recorder.
+func (it *Recorder) AssertNotLogged(t Reporter, level logx.Level) bool {
+	if helper, ok := t.(interface{ Helper() }); ok {
+		helper.Helper()
+	}
+	if it.FilterMinLevel(level).Len() == 0 {
+		return true
+	}
+	t.Errorf("no entry at or above %v is expected, but got:\n%v", level, it.FilterMinLevel(level).dump())
+	return false
+}
+
+func (it *Recorder) match(entry Entry) bool {
+	for _, match := range it.filters {
+		if !match(entry) {
+			return false
+		}
+	}
+	return true
+}
+
+// dump formats the entries one per line.
+func (it *Recorder) dump() string {
+	var lines []string
+	for _, entry := range it.All() {
+		lines = append(lines, "\t"+entry.String())
+	}
+	if len(lines) == 0 {
+		return "\t(none)"
+	}
+	return strings.Join(lines, "\n")
+}
+
+// encodeField returns the value of field as it is in Entry.Fields.
+func encodeField(field zapcore.Field) interface{} {
+	enc := zapcore.NewMapObjectEncoder()
+	field.AddTo(enc)
+	return enc.Fields[field.Key]
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2018 souhup
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logxtest
+
+import (
+	"errors"
+	"fmt"
+	"github.com/souhup/logx"
+	. "gopkg.in/check.v1"
+	"testing"
+)
+
+type MySuite struct {
+}
+
+var _ = Suite(&MySuite{})
+
+func Test(t *testing.T) { TestingT(t) }
+
+// failures is a Reporter recording the errors.
+type failures []string
+
+func (it *failures) Errorf(format string, args ...interface{}) {
+	*it = append(*it, fmt.Sprintf(format, args...))
+}
+
+func (it *MySuite) TestNewObserved(c *C) {
+	logger, recorder := NewObserved(logx.InfoLevel)
+	logger.Debug("hidden")
+	logger.With("id", 7).Info("user created")
+	logger.Named("db").WarnFields("slow query", logx.String("table", "users"), logx.Err(errors.New("timeout")))
+	logger.Errorf("failed %v times", 3)
+
+	c.Assert(recorder.Len(), Equals, 3)
+	entries := recorder.All()
+	c.Assert(entries[0].Level, Equals, logx.InfoLevel)
+	c.Assert(entries[0].Message, Equals, "user created")
+	c.Assert(entries[0].Fields, DeepEquals, map[string]interface{}{"id": int64(7)})
+	c.Assert(entries[0].Caller, Matches, "logxtest/observer_test.go:\\d+")
+
+	c.Assert(recorder.FilterLevel(logx.WarnLevel).All()[0].Name, Equals, "db")
+	c.Assert(recorder.FilterMinLevel(logx.WarnLevel).Len(), Equals, 2)
+	c.Assert(recorder.FilterMessage("times").Len(), Equals, 1)
+	c.Assert(recorder.FilterName("db").FilterField("table", "users").Len(), Equals, 1)
+	c.Assert(recorder.FilterField("id", 7).Len(), Equals, 1)
+	c.Assert(recorder.FilterField("id", 8).Len(), Equals, 0)
+
+	// filtered Recorders see the entries logged later too.
+	errs := recorder.FilterLevel(logx.ErrorLevel)
+	logger.Error("again")
+	c.Assert(errs.Len(), Equals, 2)
+
+	// the level of the Logger can be changed.
+	logger.SetLevel(logx.DebugLevel)
+	logger.Debug("shown")
+	c.Assert(recorder.FilterLevel(logx.DebugLevel).Len(), Equals, 1)
+}
+
+func (it *MySuite) TestAssertLogged(c *C) {
+	logger, recorder := NewObserved(logx.DebugLevel)
+	logger.With("id", 7).Warn("user deleted")
+
+	var t failures
+	c.Assert(recorder.AssertLogged(&t, logx.WarnLevel, "deleted", "id", 7), Equals, true)
+	c.Assert(recorder.AssertNotLogged(&t, logx.ErrorLevel), Equals, true)
+	c.Assert(t, HasLen, 0)
+
+	c.Assert(recorder.AssertLogged(&t, logx.WarnLevel, "deleted", "id", 8), Equals, false)
+	c.Assert(recorder.AssertNotLogged(&t, logx.WarnLevel), Equals, false)
+	c.Assert(t, HasLen, 2)
+	c.Assert(t[0], Matches, `(?s)no entry at warn with message "deleted" and fields \[id 8\] is logged, but got:.*user deleted.*`)
+	c.Assert(t[1], Matches, `(?s)no entry at or above warn is expected, but got:.*user deleted.*`)
+}
@@ -0,0 +1,132 @@
+// Copyright (c) 2018 souhup
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logxtest
+
+import (
+	"bytes"
+	"github.com/souhup/logx"
+	"go.uber.org/zap/zapcore"
+)
+
+// TB is the part of testing.T used by New, and *check.C of gopkg.in/check.v1
+// is a TB too.
+type TB interface {
+	Logf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// Option configures the Logger returned by New.
+type Option func(*options)
+
+type options struct {
+	level  logx.Level
+	failOn logx.Level
+	fail   bool
+}
+
+// Level sets the minimum level of the Logger. The default is debug.
+func Level(level logx.Level) Option {
+	return func(it *options) {
+		it.level = level
+	}
+}
+
+// FailOn makes the entries at or above the level fail the test. The default
+// is error.
+func FailOn(level logx.Level) Option {
+	return func(it *options) {
+		it.failOn = level
+		it.fail = true
+	}
+}
+
+// NoFail makes no entry fail the test.
+func NoFail() Option {
+	return func(it *options) {
+		it.fail = false
+	}
+}
+
+// New returns a Logger writing entries to t.Logf by the console encoder, so
+// that they are shown with the test, and only if it fails or runs verbosely.
+// The entries at or above error fail the test, see FailOn.
+func New(t TB, opts ...Option) *logx.Logger {
+	config := options{level: logx.DebugLevel, failOn: logx.ErrorLevel, fail: true}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	encoder := zapcore.NewConsoleEncoder(zapcore.EncoderConfig{
+		MessageKey:     "msg",
+		LevelKey:       "level",
+		NameKey:        "logger",
+		CallerKey:      "caller",
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeLevel:    zapcore.CapitalLevelEncoder,
+		EncodeDuration: zapcore.SecondsDurationEncoder,
+		EncodeCaller:   zapcore.ShortCallerEncoder,
+	})
+	core := zapcore.NewCore(encoder, testingWriter{t}, zapcore.DebugLevel)
+	if config.fail {
+		core = &failingCore{Core: core, t: t, level: zapcore.Level(config.failOn)}
+	}
+	return logx.GetLoggerByCore(core, config.level)
+}
+
+// testingWriter writes each entry by t.Logf.
+type testingWriter struct {
+	t TB
+}
+
+func (it testingWriter) Write(p []byte) (int, error) {
+	it.t.Logf("%s", bytes.TrimSuffix(p, []byte("\n")))
+	return len(p), nil
+}
+
+func (it testingWriter) Sync() error {
+	return nil
+}
+
+// failingCore fails the test when an entry at or above the level is written.
+type failingCore struct {
+	zapcore.Core
+	t     TB
+	level zapcore.Level
+}
+
+func (it *failingCore) With(fields []zapcore.Field) zapcore.Core {
+	return &failingCore{Core: it.Core.With(fields), t: it.t, level: it.level}
+}
+
+func (it *failingCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if it.Enabled(entry.Level) {
+		return ce.AddCore(entry, it)
+	}
+	return ce
+}
+
+func (it *failingCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	err := it.Core.Write(entry, fields)
+	if entry.Level >= it.level {
+		it.t.Errorf("unexpected entry at %v: %v", logx.Level(entry.Level), entry.Message)
+	}
+	return err
+}
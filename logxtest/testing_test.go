@@ -0,0 +1,74 @@
+// Copyright (c) 2018 souhup
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logxtest
+
+import (
+	"fmt"
+	"github.com/souhup/logx"
+	. "gopkg.in/check.v1"
+)
+
+// fakeT is a TB recording the logs and the errors.
+type fakeT struct {
+	logs   []string
+	errors []string
+}
+
+func (it *fakeT) Logf(format string, args ...interface{}) {
+	it.logs = append(it.logs, fmt.Sprintf(format, args...))
+}
+
+func (it *fakeT) Errorf(format string, args ...interface{}) {
+	it.errors = append(it.errors, fmt.Sprintf(format, args...))
+}
+
+func (it *MySuite) TestNew(c *C) {
+	t := new(fakeT)
+	logger := New(t)
+	logger.Named("db").With("id", 7).Debug("debug")
+	logger.Warn("warn")
+	c.Assert(t.logs, HasLen, 2)
+	c.Assert(t.logs[0], Matches, `DEBUG\tdb\tlogxtest/testing_test.go:\d+\tdebug\t\{"id": 7\}`)
+	c.Assert(t.logs[1], Matches, `WARN\tlogxtest/testing_test.go:\d+\twarn`)
+	c.Assert(t.errors, HasLen, 0)
+
+	logger.Error("error")
+	c.Assert(t.logs, HasLen, 3)
+	c.Assert(t.errors, DeepEquals, []string{"unexpected entry at error: error"})
+}
+
+func (it *MySuite) TestNewOptions(c *C) {
+	t := new(fakeT)
+	logger := New(t, Level(logx.InfoLevel), FailOn(logx.WarnLevel))
+	logger.Debug("hidden")
+	logger.Warn("warn")
+	c.Assert(t.logs, HasLen, 1)
+	c.Assert(t.errors, DeepEquals, []string{"unexpected entry at warn: warn"})
+
+	t = new(fakeT)
+	logger = New(t, NoFail())
+	logger.Error("error")
+	c.Assert(t.logs, HasLen, 1)
+	c.Assert(t.errors, HasLen, 0)
+
+	// *check.C is a TB too.
+	New(c).Info("to the log of the test")
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2018 souhup
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logx
+
+import (
+	"go.uber.org/zap/zapcore"
+	"strings"
+)
+
+// moduleLevels are the minimum levels of named loggers. It is replaced as a
+// whole when any level changes, so that it can be read without locking.
+type moduleLevels struct {
+	levels map[string]zapcore.Level
+	min    zapcore.Level // the lowest of levels
+}
+
+// setModules replaces the levels of modules. The caller must hold it.mu.
+func (it *coreRoot) setModules(modules map[string]Level) {
+	levels := make(map[string]zapcore.Level, len(modules))
+	for name, level := range modules {
+		levels[name] = zapcore.Level(level)
+	}
+	it.storeModules(levels)
+}
+
+// storeModules stores levels, which must not be changed after that.
+func (it *coreRoot) storeModules(levels map[string]zapcore.Level) {
+	modules := &moduleLevels{levels: levels, min: zapcore.FatalLevel}
+	for _, level := range levels {
+		if level < modules.min {
+			modules.min = level
+		}
+	}
+	it.modules.Store(modules)
+}
+
+// loadModules returns the current levels of modules.
+func (it *coreRoot) loadModules() *moduleLevels {
+	modules, _ := it.modules.Load().(*moduleLevels)
+	if modules == nil {
+		modules = &moduleLevels{}
+	}
+	return modules
+}
+
+// enabled reports whether the level is enabled for any logger, named or not.
+func (it *coreRoot) enabled(level zapcore.Level) bool {
+	if it.level.Enabled(level) {
+		return true
+	}
+	modules := it.loadModules()
+	return len(modules.levels) > 0 && level >= modules.min
+}
+
+// enabledFor reports whether the level is enabled for the logger named name.
+func (it *coreRoot) enabledFor(name string, level zapcore.Level) bool {
+	return level >= it.levelOf(name)
+}
+
+// levelOf returns the level of the closest module of the logger named name,
+// such as db.pool and then db for db.pool.conn, or the level of the Logger
+// if there is none.
+func (it *coreRoot) levelOf(name string) zapcore.Level {
+	modules := it.loadModules()
+	for len(modules.levels) > 0 && name != "" {
+		if level, ok := modules.levels[name]; ok {
+			return level
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			break
+		}
+		name = name[:i]
+	}
+	return it.level.Level()
+}
+
+// SetModuleLevel changes the minimum level of the loggers named name and of
+// their descendants at runtime, see Config.Modules. It affects all loggers
+// sharing the configuration with the Logger, until the next Reload.
+func (it *Logger) SetModuleLevel(name string, level Level) {
+	it.root.mu.Lock()
+	defer it.root.mu.Unlock()
+	levels := it.copyModules()
+	levels[name] = zapcore.Level(level)
+	it.root.storeModules(levels)
+}
+
+// UnsetModuleLevel removes the minimum level of the loggers named name, so
+// that they inherit the level of their closest module or of the Logger again.
+func (it *Logger) UnsetModuleLevel(name string) {
+	it.root.mu.Lock()
+	defer it.root.mu.Unlock()
+	levels := it.copyModules()
+	delete(levels, name)
+	it.root.storeModules(levels)
+}
+
+// GetModuleLevel returns the minimum level of the loggers named name, which
+// is inherited from the closest module or the Logger if it is not set.
+func (it *Logger) GetModuleLevel(name string) Level {
+	return Level(it.root.levelOf(name))
+}
+
+// copyModules returns a copy of the current levels of modules.
+func (it *Logger) copyModules() map[string]zapcore.Level {
+	current := it.root.loadModules().levels
+	levels := make(map[string]zapcore.Level, len(current)+1)
+	for name, level := range current {
+		levels[name] = level
+	}
+	return levels
+}
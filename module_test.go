@@ -0,0 +1,90 @@
+// Copyright (c) 2018 souhup
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logx
+
+import (
+	. "gopkg.in/check.v1"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+func (it *MySuite) TestNamed(c *C) {
+	filename := filepath.Join(c.MkDir(), "named.log")
+	logger, err := GetLoggerByConf(&Config{
+		Level:      InfoLevel,
+		Modules:    map[string]Level{"db": WarnLevel, "db.pool": DebugLevel},
+		MessageKey: "msg",
+		NameKey:    "logger",
+		Encoding:   "json",
+		Filename:   filename,
+	})
+	c.Assert(err, IsNil)
+
+	db := logger.Named("db")
+	pool := db.Named("pool")
+	c.Assert(logger.GetModuleLevel("db.pool.conn"), Equals, DebugLevel)
+	c.Assert(logger.GetModuleLevel("db.cache"), Equals, WarnLevel)
+	c.Assert(logger.GetModuleLevel("http"), Equals, InfoLevel)
+
+	logger.Debug("root debug")
+	logger.Info("root info")
+	db.Info("db info")
+	db.Named("cache").Warn("cache warn")
+	pool.Debug("pool debug")
+	pool.Named("conn").With("id", 1).Debug("conn debug")
+	logger.Named("http").Info("http info")
+
+	// levels of modules change at runtime, and unset ones are inherited.
+	logger.SetModuleLevel("db", ErrorLevel)
+	logger.UnsetModuleLevel("db.pool")
+	c.Assert(logger.GetModuleLevel("db.pool"), Equals, ErrorLevel)
+	pool.Warn("pool warn")
+	logger.SetModuleLevel("http", DebugLevel)
+	logger.Named("http").Debug("http debug")
+	logger.Flush()
+
+	data, err := ioutil.ReadFile(filename)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Split(strings.TrimSpace(string(data)), "\n"), DeepEquals, []string{
+		`{"msg":"root info"}`,
+		`{"logger":"db.cache","msg":"cache warn"}`,
+		`{"logger":"db.pool","msg":"pool debug"}`,
+		`{"logger":"db.pool.conn","msg":"conn debug","id":1}`,
+		`{"logger":"http","msg":"http info"}`,
+		`{"logger":"http","msg":"http debug"}`,
+	})
+
+	// Reload replaces the levels of modules.
+	c.Assert(logger.Reload(&Config{Level: WarnLevel, MessageKey: "msg", Encoding: "json", Filename: filename}), IsNil)
+	c.Assert(logger.GetModuleLevel("http"), Equals, WarnLevel)
+}
+
+func (it *MySuite) TestModulesConfig(c *C) {
+	config, err := parseConfig("modules", "yaml", []byte("modules:\n  db: warn\n  db.pool: -1\n"))
+	c.Assert(err, IsNil)
+	c.Assert(config.Modules, DeepEquals, map[string]Level{"db": WarnLevel, "db.pool": DebugLevel})
+	c.Assert(config.NameKey, Equals, "logger")
+
+	config = &Config{MessageKey: "msg", Encoding: "json", Modules: map[string]Level{"db": 7, "": InfoLevel}}
+	c.Assert(config.Validate(), ErrorMatches, "name of modules must not be empty; "+
+		"modules.db must be one of the debug, info, warn, error, dpanic, panic or fatal, but got 7")
+}
@@ -0,0 +1,533 @@
+// Copyright (c) 2018 souhup
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logx
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Redact conceals sensitive values before entries are encoded. Fields of
+// which the keys match Keys are concealed as a whole, and the parts of
+// messages and string values matching Patterns are concealed in place.
+type Redact struct {
+	// Keys are the glob patterns of keys of fields, such as password or
+	// *_token, matched case-insensitively.
+	Keys []string `yaml:"keys"`
+
+	// Patterns are the regular expressions of sensitive values, or the
+	// names of the builtin ones: credit_card, bearer_token and email.
+	// credit_card only matches numbers passing the Luhn checksum.
+	Patterns []string `yaml:"patterns"`
+
+	// Strategy is how values are concealed, just is mask, hash or drop.
+	// Mask replaces them by ***, hash replaces them by a HMAC-SHA256 of them
+	// with HashKey, so that equal values can still be correlated, and drop
+	// removes them. The default is mask.
+	Strategy string `yaml:"strategy"`
+
+	// HashKey is the key of the HMAC of hash.
+	HashKey string `yaml:"hash_key"`
+}
+
+const (
+	redactMask = "mask"
+	redactHash = "hash"
+	redactDrop = "drop"
+
+	// maskedValue replaces the values concealed by mask.
+	maskedValue = "***"
+)
+
+// builtinPatterns are the patterns of Redact known by names.
+var builtinPatterns = map[string]string{
+	"credit_card":  `\b(?:\d[ -]?){12,18}\d\b`,
+	"bearer_token": `(?i)\bbearer\s+[A-Za-z0-9\-._~+/]+=*`,
+	"email":        `[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`,
+}
+
+// builtinChecks tell whether a match of a builtin pattern is concealed, so
+// that numbers such as timestamps are not taken for credit cards.
+var builtinChecks = map[string]func(string) bool{
+	"credit_card": luhnValid,
+}
+
+// luhnValid reports whether the digits of s pass the Luhn checksum of card
+// numbers.
+func luhnValid(s string) bool {
+	sum, double := 0, false
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i] < '0' || s[i] > '9' {
+			continue
+		}
+		digit := int(s[i] - '0')
+		if double {
+			if digit *= 2; digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// compilePattern compiles a pattern of Redact.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if builtin, ok := builtinPatterns[pattern]; ok {
+		pattern = builtin
+	}
+	return regexp.Compile(pattern)
+}
+
+// redactor conceals values by Redact.
+type redactor struct {
+	keys     []string
+	patterns []*regexp.Regexp
+	checks   []func(string) bool // the checks of patterns, nil for none
+	strategy string
+	hashKey  []byte
+}
+
+func newRedactor(config *Redact) (*redactor, error) {
+	it := &redactor{strategy: config.Strategy, hashKey: []byte(config.HashKey)}
+	if it.strategy == "" {
+		it.strategy = redactMask
+	}
+	for _, key := range config.Keys {
+		it.keys = append(it.keys, strings.ToLower(key))
+	}
+	for _, pattern := range config.Patterns {
+		re, err := compilePattern(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("compile redact pattern %q, error: %v", pattern, err)
+		}
+		it.patterns = append(it.patterns, re)
+		it.checks = append(it.checks, builtinChecks[pattern])
+	}
+	return it, nil
+}
+
+// matchKey reports whether the values of key are concealed as a whole.
+func (it *redactor) matchKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, pattern := range it.keys {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// replace conceals the parts of s matching the patterns.
+func (it *redactor) replace(s string) string {
+	for i, re := range it.patterns {
+		if !re.MatchString(s) {
+			continue
+		}
+		check := it.checks[i]
+		s = re.ReplaceAllStringFunc(s, func(match string) string {
+			if check != nil && !check(match) {
+				return match
+			}
+			return it.conceal(match)
+		})
+	}
+	return s
+}
+
+// conceal returns what replaces s.
+func (it *redactor) conceal(s string) string {
+	switch it.strategy {
+	case redactHash:
+		mac := hmac.New(sha256.New, it.hashKey)
+		mac.Write([]byte(s))
+		return "hmac:" + hex.EncodeToString(mac.Sum(nil))
+	case redactDrop:
+		return ""
+	}
+	return maskedValue
+}
+
+// fields conceals fields. It returns fields itself if nothing is concealed.
+func (it *redactor) fields(fields []zapcore.Field) []zapcore.Field {
+	var redacted []zapcore.Field
+	for i, field := range fields {
+		concealed, changed, keep := it.field(field)
+		if redacted == nil && !changed {
+			continue
+		}
+		if redacted == nil {
+			redacted = make([]zapcore.Field, i, len(fields))
+			copy(redacted, fields[:i])
+		}
+		if keep {
+			redacted = append(redacted, concealed)
+		}
+	}
+	if redacted == nil {
+		return fields
+	}
+	return redacted
+}
+
+// field conceals a field, and reports whether it is changed and whether it
+// is kept. Objects, arrays and reflected values are concealed inside too.
+func (it *redactor) field(field zapcore.Field) (concealed zapcore.Field, changed, keep bool) {
+	if it.matchKey(field.Key) {
+		if it.strategy == redactDrop {
+			return field, true, false
+		}
+		return String(field.Key, it.conceal(fieldString(field))), true, true
+	}
+
+	switch field.Type {
+	case zapcore.StringType, zapcore.ByteStringType, zapcore.ErrorType, zapcore.StringerType:
+		if len(it.patterns) == 0 {
+			break
+		}
+		value := fieldString(field)
+		if s := it.replace(value); s != value {
+			return String(field.Key, s), true, true
+		}
+	case zapcore.ObjectMarshalerType:
+		return zap.Object(field.Key, redactedObject{field.Interface.(zapcore.ObjectMarshaler), it}), true, true
+	case zapcore.ArrayMarshalerType:
+		return zap.Array(field.Key, redactedArray{field.Interface.(zapcore.ArrayMarshaler), it}), true, true
+	case zapcore.ReflectType:
+		return zap.Reflect(field.Key, it.reflected(field.Interface)), true, true
+	}
+	return field, false, true
+}
+
+// fieldString returns the value of field as a string.
+func fieldString(field zapcore.Field) string {
+	switch field.Type {
+	case zapcore.StringType:
+		return field.String
+	case zapcore.ByteStringType:
+		return string(field.Interface.([]byte))
+	case zapcore.ErrorType:
+		return field.Interface.(error).Error()
+	case zapcore.StringerType:
+		return field.Interface.(fmt.Stringer).String()
+	}
+	enc := zapcore.NewMapObjectEncoder()
+	field.AddTo(enc)
+	return fmt.Sprint(enc.Fields[field.Key])
+}
+
+// reflected conceals a value encoded by json, by its generic form decoded
+// from json, of which the keys and the strings are concealed. Values which
+// can not be encoded are left to the encoder.
+func (it *redactor) reflected(value interface{}) interface{} {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var generic interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&generic); err != nil {
+		return value
+	}
+	return it.generic(generic)
+}
+
+// generic conceals a value decoded from json in place.
+func (it *redactor) generic(value interface{}) interface{} {
+	switch value := value.(type) {
+	case string:
+		return it.replace(value)
+	case []interface{}:
+		for i, element := range value {
+			value[i] = it.generic(element)
+		}
+	case map[string]interface{}:
+		for key, element := range value {
+			switch {
+			case !it.matchKey(key):
+				value[key] = it.generic(element)
+			case it.strategy == redactDrop:
+				delete(value, key)
+			default:
+				value[key] = it.conceal(fmt.Sprint(element))
+			}
+		}
+	}
+	return value
+}
+
+// redactedObject conceals the keys and the values added by an object.
+type redactedObject struct {
+	zapcore.ObjectMarshaler
+	redactor *redactor
+}
+
+func (it redactedObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	return it.ObjectMarshaler.MarshalLogObject(&redactObjectEncoder{enc, it.redactor})
+}
+
+// redactedArray conceals the values appended by an array.
+type redactedArray struct {
+	zapcore.ArrayMarshaler
+	redactor *redactor
+}
+
+func (it redactedArray) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	return it.ArrayMarshaler.MarshalLogArray(&redactArrayEncoder{enc, it.redactor})
+}
+
+// redactObjectEncoder is a zapcore.ObjectEncoder which conceals the values
+// of which the keys match, and the strings matching the patterns.
+type redactObjectEncoder struct {
+	zapcore.ObjectEncoder
+	redactor *redactor
+}
+
+// concealKey adds the concealed value under key and returns true if key
+// matches, otherwise it returns false to add value as it is.
+func (it *redactObjectEncoder) concealKey(key string, value interface{}) bool {
+	if !it.redactor.matchKey(key) {
+		return false
+	}
+	if it.redactor.strategy != redactDrop {
+		it.ObjectEncoder.AddString(key, it.redactor.conceal(fmt.Sprint(value)))
+	}
+	return true
+}
+
+func (it *redactObjectEncoder) AddArray(key string, value zapcore.ArrayMarshaler) error {
+	if it.redactor.matchKey(key) {
+		it.concealKey(key, fieldString(zap.Array(key, value)))
+		return nil
+	}
+	return it.ObjectEncoder.AddArray(key, redactedArray{value, it.redactor})
+}
+
+func (it *redactObjectEncoder) AddObject(key string, value zapcore.ObjectMarshaler) error {
+	if it.redactor.matchKey(key) {
+		it.concealKey(key, fieldString(zap.Object(key, value)))
+		return nil
+	}
+	return it.ObjectEncoder.AddObject(key, redactedObject{value, it.redactor})
+}
+
+func (it *redactObjectEncoder) AddReflected(key string, value interface{}) error {
+	if it.concealKey(key, value) {
+		return nil
+	}
+	return it.ObjectEncoder.AddReflected(key, it.redactor.reflected(value))
+}
+
+func (it *redactObjectEncoder) AddString(key, value string) {
+	if !it.concealKey(key, value) {
+		it.ObjectEncoder.AddString(key, it.redactor.replace(value))
+	}
+}
+
+func (it *redactObjectEncoder) AddByteString(key string, value []byte) {
+	if !it.concealKey(key, string(value)) {
+		it.ObjectEncoder.AddString(key, it.redactor.replace(string(value)))
+	}
+}
+
+func (it *redactObjectEncoder) AddBinary(key string, value []byte) {
+	if !it.concealKey(key, value) {
+		it.ObjectEncoder.AddBinary(key, value)
+	}
+}
+
+func (it *redactObjectEncoder) AddBool(key string, value bool) {
+	if !it.concealKey(key, value) {
+		it.ObjectEncoder.AddBool(key, value)
+	}
+}
+
+func (it *redactObjectEncoder) AddComplex128(key string, value complex128) {
+	if !it.concealKey(key, value) {
+		it.ObjectEncoder.AddComplex128(key, value)
+	}
+}
+
+func (it *redactObjectEncoder) AddComplex64(key string, value complex64) {
+	if !it.concealKey(key, value) {
+		it.ObjectEncoder.AddComplex64(key, value)
+	}
+}
+
+func (it *redactObjectEncoder) AddDuration(key string, value time.Duration) {
+	if !it.concealKey(key, value) {
+		it.ObjectEncoder.AddDuration(key, value)
+	}
+}
+
+func (it *redactObjectEncoder) AddFloat64(key string, value float64) {
+	if !it.concealKey(key, value) {
+		it.ObjectEncoder.AddFloat64(key, value)
+	}
+}
+
+func (it *redactObjectEncoder) AddFloat32(key string, value float32) {
+	if !it.concealKey(key, value) {
+		it.ObjectEncoder.AddFloat32(key, value)
+	}
+}
+
+func (it *redactObjectEncoder) AddInt(key string, value int) {
+	if !it.concealKey(key, value) {
+		it.ObjectEncoder.AddInt(key, value)
+	}
+}
+
+func (it *redactObjectEncoder) AddInt64(key string, value int64) {
+	if !it.concealKey(key, value) {
+		it.ObjectEncoder.AddInt64(key, value)
+	}
+}
+
+func (it *redactObjectEncoder) AddInt32(key string, value int32) {
+	if !it.concealKey(key, value) {
+		it.ObjectEncoder.AddInt32(key, value)
+	}
+}
+
+func (it *redactObjectEncoder) AddInt16(key string, value int16) {
+	if !it.concealKey(key, value) {
+		it.ObjectEncoder.AddInt16(key, value)
+	}
+}
+
+func (it *redactObjectEncoder) AddInt8(key string, value int8) {
+	if !it.concealKey(key, value) {
+		it.ObjectEncoder.AddInt8(key, value)
+	}
+}
+
+func (it *redactObjectEncoder) AddTime(key string, value time.Time) {
+	if !it.concealKey(key, value) {
+		it.ObjectEncoder.AddTime(key, value)
+	}
+}
+
+func (it *redactObjectEncoder) AddUint(key string, value uint) {
+	if !it.concealKey(key, value) {
+		it.ObjectEncoder.AddUint(key, value)
+	}
+}
+
+func (it *redactObjectEncoder) AddUint64(key string, value uint64) {
+	if !it.concealKey(key, value) {
+		it.ObjectEncoder.AddUint64(key, value)
+	}
+}
+
+func (it *redactObjectEncoder) AddUint32(key string, value uint32) {
+	if !it.concealKey(key, value) {
+		it.ObjectEncoder.AddUint32(key, value)
+	}
+}
+
+func (it *redactObjectEncoder) AddUint16(key string, value uint16) {
+	if !it.concealKey(key, value) {
+		it.ObjectEncoder.AddUint16(key, value)
+	}
+}
+
+func (it *redactObjectEncoder) AddUint8(key string, value uint8) {
+	if !it.concealKey(key, value) {
+		it.ObjectEncoder.AddUint8(key, value)
+	}
+}
+
+func (it *redactObjectEncoder) AddUintptr(key string, value uintptr) {
+	if !it.concealKey(key, value) {
+		it.ObjectEncoder.AddUintptr(key, value)
+	}
+}
+
+// redactArrayEncoder is a zapcore.ArrayEncoder which conceals the strings
+// matching the patterns, and the nested objects and arrays.
+type redactArrayEncoder struct {
+	zapcore.ArrayEncoder
+	redactor *redactor
+}
+
+func (it *redactArrayEncoder) AppendArray(value zapcore.ArrayMarshaler) error {
+	return it.ArrayEncoder.AppendArray(redactedArray{value, it.redactor})
+}
+
+func (it *redactArrayEncoder) AppendObject(value zapcore.ObjectMarshaler) error {
+	return it.ArrayEncoder.AppendObject(redactedObject{value, it.redactor})
+}
+
+func (it *redactArrayEncoder) AppendReflected(value interface{}) error {
+	return it.ArrayEncoder.AppendReflected(it.redactor.reflected(value))
+}
+
+func (it *redactArrayEncoder) AppendString(value string) {
+	it.ArrayEncoder.AppendString(it.redactor.replace(value))
+}
+
+func (it *redactArrayEncoder) AppendByteString(value []byte) {
+	it.ArrayEncoder.AppendString(it.redactor.replace(string(value)))
+}
+
+// redactCore is a zapcore.Core which conceals the messages and the fields of
+// entries before the wrapped core encodes them.
+type redactCore struct {
+	zapcore.Core
+	redactor    *redactor
+	errorOutput zapcore.WriteSyncer
+}
+
+func (it *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{
+		Core:        it.Core.With(it.redactor.fields(fields)),
+		redactor:    it.redactor,
+		errorOutput: it.errorOutput,
+	}
+}
+
+// Check adds the core itself to ce, since the fields of the entry are only
+// known by Write.
+func (it *redactCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !it.Enabled(entry.Level) {
+		return ce
+	}
+	return ce.AddCore(entry, it)
+}
+
+func (it *redactCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	entry.Message = it.redactor.replace(entry.Message)
+	writeChecked(it.Core, entry, it.redactor.fields(fields), it.errorOutput)
+	return nil
+}
@@ -0,0 +1,168 @@
+// Copyright (c) 2018 souhup
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logx
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"go.uber.org/zap"
+	. "gopkg.in/check.v1"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+func (it *MySuite) TestRedact(c *C) {
+	for _, t := range []struct {
+		strategy string
+		lines    []string
+	}{
+		{"", []string{
+			`{"msg":"login by ***","user":"bob","password":"***"}`,
+			`{"msg":"request","header":"***","pin":"***","note":"card *** expired"}`,
+			`{"msg":"took 1697500000000 ms"}`,
+		}},
+		{"hash", []string{
+			`{"msg":"login by ` + hmacOf("bob@example.com") + `","user":"bob","password":"` + hmacOf("hunter2") + `"}`,
+			`{"msg":"request","header":"` + hmacOf("Bearer abc.def") + `","pin":"` + hmacOf("1234") +
+				`","note":"card ` + hmacOf("4111 1111 1111 1111") + ` expired"}`,
+			`{"msg":"took 1697500000000 ms"}`,
+		}},
+		{"drop", []string{
+			`{"msg":"login by ","user":"bob"}`,
+			`{"msg":"request","header":"","note":"card  expired"}`,
+			`{"msg":"took 1697500000000 ms"}`,
+		}},
+	} {
+		filename := filepath.Join(c.MkDir(), "redact.log")
+		logger, err := GetLoggerByConf(&Config{
+			MessageKey: "msg",
+			Encoding:   "json",
+			Filename:   filename,
+			Redact: &Redact{
+				Keys:     []string{"password", "*PIN"},
+				Patterns: []string{"email", "bearer_token", "credit_card"},
+				Strategy: t.strategy,
+				HashKey:  "secret",
+			},
+		})
+		c.Assert(err, IsNil)
+
+		logger.With("user", "bob", "password", "hunter2").Info("login by bob@example.com")
+		logger.InfoFields("request", String("header", "Bearer abc.def"), Int("pin", 1234),
+			String("note", "card 4111 1111 1111 1111 expired"))
+		// numbers failing the Luhn checksum are not cards.
+		logger.Info("took 1697500000000 ms")
+		logger.Flush()
+
+		data, err := ioutil.ReadFile(filename)
+		c.Assert(err, IsNil)
+		c.Assert(strings.Split(strings.TrimSpace(string(data)), "\n"), DeepEquals, t.lines, Commentf("strategy %q", t.strategy))
+	}
+}
+
+func (it *MySuite) TestRedactConfig(c *C) {
+	config, err := parseConfig("redact", "yaml", []byte("redact:\n  keys: [password, '*_token']\n"+
+		"  patterns: [email, 'id=\\d+']\n  strategy: hash\n  hash_key: secret\n"))
+	c.Assert(err, IsNil)
+	c.Assert(config.Redact, DeepEquals, &Redact{
+		Keys:     []string{"password", "*_token"},
+		Patterns: []string{"email", `id=\d+`},
+		Strategy: "hash",
+		HashKey:  "secret",
+	})
+
+	config = &Config{MessageKey: "msg", Encoding: "json", Redact: &Redact{
+		Keys:     []string{"[a"},
+		Patterns: []string{"(a"},
+		Strategy: "hash",
+	}}
+	c.Assert(config.Validate(), ErrorMatches, `redact.keys must be glob patterns, but got "\[a"; `+
+		`redact.patterns must be credit_card, bearer_token, email or regular expressions, but got "\(a", error: .*; `+
+		`redact.hash_key must not be empty for the strategy hash`)
+	config.Redact = &Redact{Strategy: "encrypt"}
+	c.Assert(config.Validate(), ErrorMatches, `redact.strategy must be one of the mask, hash or drop, but got "encrypt"`)
+}
+
+// hmacOf is the value concealed by hash with the key secret.
+func hmacOf(s string) string {
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(s))
+	return "hmac:" + hex.EncodeToString(mac.Sum(nil))
+}
+
+type testLogin struct {
+	User     string
+	Password string
+	Header   string
+	Devices  []testDevice
+}
+
+type testDevice struct {
+	Name  string
+	Token string
+}
+
+func (it *MySuite) TestRedactNested(c *C) {
+	filename := filepath.Join(c.MkDir(), "nested.log")
+	logger, err := GetLoggerByConf(&Config{
+		MessageKey: "msg",
+		Encoding:   "json",
+		Filename:   filename,
+		Redact: &Redact{
+			Keys:     []string{"password", "token"},
+			Patterns: []string{"bearer_token"},
+		},
+	})
+	c.Assert(err, IsNil)
+
+	login := testLogin{
+		User:     "bob",
+		Password: "hunter2",
+		Header:   "Bearer abc.def",
+		Devices:  []testDevice{{Name: "phone", Token: "t0k3n"}},
+	}
+	// objects of struct arguments, reflected values and errors are concealed
+	// inside too.
+	logger.Info("login", login)
+	logger.With("login", login).Info("with")
+	logger.With("m", map[string]interface{}{"token": 1, "note": "Bearer abc.def"}).Info("map")
+	logger.ErrorFields("failed", Err(errors.New("auth Bearer abc.def")), Stringer("s", testStringer("Bearer abc.def")),
+		zap.ByteString("b", []byte("Bearer abc.def")))
+	logger.Flush()
+
+	data, err := ioutil.ReadFile(filename)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Split(strings.TrimSpace(string(data)), "\n"), DeepEquals, []string{
+		`{"msg":"login","testLogin":{"User":"bob","Password":"***","Header":"***","Devices":[{"Name":"phone","Token":"***"}]}}`,
+		`{"msg":"with","login":{"Devices":[{"Name":"phone","Token":"***"}],"Header":"***","Password":"***","User":"bob"}}`,
+		`{"msg":"map","m":{"note":"***","token":"***"}}`,
+		`{"msg":"failed","error":"auth ***","s":"***","b":"***"}`,
+	})
+}
+
+type testStringer string
+
+func (it testStringer) String() string {
+	return string(it)
+}
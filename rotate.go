@@ -0,0 +1,523 @@
+// Copyright (c) 2018 souhup
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logx
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	backupTimeFormat = "2006-01-02T15-04-05.000"
+	defaultMaxSize   = 100
+	megabyte         = 1024 * 1024
+)
+
+// clock holds the func() time.Time returning the current time. It exists so
+// it can be mocked out by tests, while files of other tests are milled in
+// the background.
+var clock atomic.Value
+
+func init() {
+	clock.Store(time.Now)
+}
+
+// currentTime returns the current time by clock.
+func currentTime() time.Time {
+	return clock.Load().(func() time.Time)()
+}
+
+// rollingFile is an io.WriteCloser that writes to the specified file and
+// rotates it once it grows beyond maxSize or every interval, whichever comes
+// first. Rotated files are renamed to <name>-<time><ext>, then compressed and
+// removed according to compressor, maxAge and maxBackups.
+type rollingFile struct {
+	rollingConfig
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	closed bool
+
+	// the period covered by the current file, only used with interval.
+	periodStart time.Time
+	periodEnd   time.Time
+
+	millCh    chan struct{}
+	startMill sync.Once
+}
+
+// rollingConfig is the settings of a rollingFile.
+type rollingConfig struct {
+	filename      string
+	maxSize       int
+	maxAge        int
+	maxBackups    int
+	localTime     bool
+	compressor    Compressor
+	compressLevel int
+	interval      time.Duration
+	align         bool
+	timeFormat    string
+}
+
+// logInfo is a rotated log file and the time encoded in its name.
+type logInfo struct {
+	timestamp  time.Time
+	seq        int
+	name       string
+	compressed bool
+}
+
+// Write implements io.Writer. It rotates the file if the write would exceed
+// maxSize or the current period has ended.
+func (it *rollingFile) Write(p []byte) (n int, err error) {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+
+	if it.closed {
+		return 0, fmt.Errorf("write to closed log file %v", it.filename)
+	}
+	writeLen := int64(len(p))
+	if writeLen > it.max() {
+		return 0, fmt.Errorf("write length %d exceeds maximum file size %d", writeLen, it.max())
+	}
+	if it.file == nil {
+		if err = it.openExistingOrNew(writeLen); err != nil {
+			return
+		}
+		it.startPeriod()
+	}
+	if it.interval > 0 && !currentTime().Before(it.periodEnd) {
+		if err = it.rotate(it.periodStart); err != nil {
+			return
+		}
+		it.startPeriod()
+	} else if it.size+writeLen > it.max() {
+		if err = it.rotate(it.backupTime()); err != nil {
+			return
+		}
+	}
+	n, err = it.file.Write(p)
+	it.size += int64(n)
+	return
+}
+
+// configure replaces the settings of the file. The file name must not change.
+func (it *rollingFile) configure(conf rollingConfig) {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+	restart := conf.interval != it.interval || conf.align != it.align
+	it.rollingConfig = conf
+	if it.file != nil && restart {
+		it.startPeriod()
+	}
+}
+
+// Sync commits the current contents of the file to stable storage.
+func (it *rollingFile) Sync() error {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+	if it.file == nil {
+		return nil
+	}
+	return it.file.Sync()
+}
+
+// Close closes the file and stops the background cleanup. A later Write
+// fails instead of opening the file again, so that the handle is not leaked.
+func (it *rollingFile) Close() error {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+	it.closed = true
+	if it.millCh != nil {
+		close(it.millCh)
+		it.millCh = nil
+		it.startMill = sync.Once{}
+	}
+	return it.close()
+}
+
+func (it *rollingFile) close() (err error) {
+	if it.file == nil {
+		return
+	}
+	err = it.file.Close()
+	it.file = nil
+	return
+}
+
+// rotate closes the current file, moves it aside to the backup named by t
+// and opens a new one.
+func (it *rollingFile) rotate(t time.Time) (err error) {
+	if err = it.close(); err != nil {
+		return
+	}
+	if err = it.openNew(t); err != nil {
+		return
+	}
+	it.mill()
+	return
+}
+
+// openExistingOrNew opens the file if it exists and the write would not
+// exceed maxSize, and opens a new file otherwise.
+func (it *rollingFile) openExistingOrNew(writeLen int64) error {
+	it.mill()
+	info, err := os.Stat(it.filename)
+	if os.IsNotExist(err) {
+		return it.openNew(currentTime())
+	}
+	if err != nil {
+		return fmt.Errorf("get info of log file %v, error: %v", it.filename, err)
+	}
+	// an existing file written in an earlier period belongs to that period.
+	if it.interval > 0 && it.align {
+		if start := it.alignTime(info.ModTime()); start.Before(it.alignTime(currentTime())) {
+			return it.rotate(start)
+		}
+	}
+	if info.Size()+writeLen > it.max() {
+		return it.rotate(currentTime())
+	}
+	file, err := os.OpenFile(it.filename, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return it.openNew(currentTime())
+	}
+	it.file = file
+	it.size = info.Size()
+	return nil
+}
+
+// openNew moves the existing file aside to the backup named by t and opens a
+// new one in its place.
+func (it *rollingFile) openNew(t time.Time) error {
+	err := os.MkdirAll(filepath.Dir(it.filename), 0755)
+	if err != nil {
+		return fmt.Errorf("make directories for log file %v, error: %v", it.filename, err)
+	}
+
+	mode := os.FileMode(0644)
+	info, err := os.Stat(it.filename)
+	if err == nil {
+		mode = info.Mode()
+		backup := it.backupName(t)
+		if err = os.Rename(it.filename, backup); err != nil {
+			return fmt.Errorf("rename log file %v, error: %v", it.filename, err)
+		}
+	}
+
+	file, err := os.OpenFile(it.filename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return fmt.Errorf("open log file %v, error: %v", it.filename, err)
+	}
+	it.file = file
+	it.size = 0
+	return nil
+}
+
+// startPeriod starts the period covered by the current file.
+func (it *rollingFile) startPeriod() {
+	if it.interval <= 0 {
+		return
+	}
+	now := currentTime()
+	if it.align {
+		it.periodStart = it.alignTime(now)
+	} else {
+		it.periodStart = now
+	}
+	it.periodEnd = it.periodStart.Add(it.interval)
+}
+
+// alignTime returns the start of the interval containing t, counting
+// intervals from midnight in the time zone used for backup names.
+func (it *rollingConfig) alignTime(t time.Time) time.Time {
+	if !it.localTime {
+		return t.UTC().Truncate(it.interval)
+	}
+	_, offset := t.Zone()
+	shift := time.Duration(offset) * time.Second
+	return t.Add(shift).Truncate(it.interval).Add(-shift)
+}
+
+// backupTime returns the time naming a backup rotated because of its size.
+// Within a period it is the start of the period, so that all backups of the
+// period share their name.
+func (it *rollingFile) backupTime() time.Time {
+	if it.interval > 0 {
+		return it.periodStart
+	}
+	return currentTime()
+}
+
+// backupName returns an unused name for the backup named by t. If the name
+// is taken, a sequence number is appended to the time, such as
+// app-2026-10-17T13.1.log.
+func (it *rollingConfig) backupName(t time.Time) string {
+	dir, prefix, ext := it.nameParts()
+	if !it.localTime {
+		t = t.UTC()
+	}
+	stamp := t.Format(it.layout())
+	name := filepath.Join(dir, prefix+stamp+ext)
+	for seq := 1; it.exists(name); seq++ {
+		name = filepath.Join(dir, prefix+stamp+"."+strconv.Itoa(seq)+ext)
+	}
+	return name
+}
+
+// exists reports whether name or a compressed form of it exists.
+func (it *rollingConfig) exists(name string) bool {
+	if _, err := os.Stat(name); err == nil {
+		return true
+	}
+	for _, ext := range compressedExtensions() {
+		if _, err := os.Stat(name + ext); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (it *rollingConfig) layout() string {
+	if it.timeFormat == "" {
+		return backupTimeFormat
+	}
+	return it.timeFormat
+}
+
+// nameParts splits filename into its directory, the prefix of backup names
+// and the extension.
+func (it *rollingConfig) nameParts() (dir, prefix, ext string) {
+	dir = filepath.Dir(it.filename)
+	base := filepath.Base(it.filename)
+	ext = filepath.Ext(base)
+	prefix = base[:len(base)-len(ext)] + "-"
+	return
+}
+
+func (it *rollingConfig) max() int64 {
+	if it.maxSize == 0 {
+		return int64(defaultMaxSize * megabyte)
+	}
+	return int64(it.maxSize) * int64(megabyte)
+}
+
+// mill asks the background goroutine to compress and remove old backups.
+func (it *rollingFile) mill() {
+	it.startMill.Do(func() {
+		it.millCh = make(chan struct{}, 1)
+		go it.millRun(it.millCh)
+	})
+	select {
+	case it.millCh <- struct{}{}:
+	default:
+	}
+}
+
+func (it *rollingFile) millRun(ch <-chan struct{}) {
+	for range ch {
+		it.mu.Lock()
+		conf := it.rollingConfig
+		it.mu.Unlock()
+		if err := conf.millRunOnce(); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+		}
+	}
+}
+
+// millRunOnce removes backups beyond maxBackups or older than maxAge, and
+// compresses the remaining ones.
+func (it *rollingConfig) millRunOnce() error {
+	if it.maxBackups == 0 && it.maxAge == 0 && it.compressor == nil {
+		return nil
+	}
+	files, err := it.oldLogFiles()
+	if err != nil {
+		return err
+	}
+
+	var remove []logInfo
+	if it.maxBackups > 0 && it.maxBackups < len(files) {
+		preserved := make(map[string]bool)
+		var remaining []logInfo
+		for _, f := range files {
+			// a backup and its compressed form count only once.
+			name := f.name
+			if f.compressed {
+				name = name[:len(name)-len(filepath.Ext(name))]
+			}
+			preserved[name] = true
+			if len(preserved) > it.maxBackups {
+				remove = append(remove, f)
+			} else {
+				remaining = append(remaining, f)
+			}
+		}
+		files = remaining
+	}
+	if it.maxAge > 0 {
+		cutoff := currentTime().Add(-time.Duration(it.maxAge) * 24 * time.Hour)
+		var remaining []logInfo
+		for _, f := range files {
+			if f.timestamp.Before(cutoff) {
+				remove = append(remove, f)
+			} else {
+				remaining = append(remaining, f)
+			}
+		}
+		files = remaining
+	}
+
+	dir := filepath.Dir(it.filename)
+	for _, f := range remove {
+		if e := os.Remove(filepath.Join(dir, f.name)); e != nil && !os.IsNotExist(e) && err == nil {
+			err = e
+		}
+	}
+	if it.compressor != nil {
+		for _, f := range files {
+			if f.compressed {
+				continue
+			}
+			name := filepath.Join(dir, f.name)
+			if e := it.compressFile(name, name+it.compressor.Extension()); e != nil && err == nil {
+				err = e
+			}
+		}
+	}
+	return err
+}
+
+// oldLogFiles returns the backups in the directory of filename, newest first.
+func (it *rollingConfig) oldLogFiles() ([]logInfo, error) {
+	dir, prefix, ext := it.nameParts()
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read log directory %v, error: %v", dir, err)
+	}
+
+	var files []logInfo
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		name := e.Name()
+		if t, seq, ok := it.timeFromName(name, prefix, ext); ok {
+			files = append(files, logInfo{t, seq, name, false})
+			continue
+		}
+		for _, cext := range compressedExtensions() {
+			if t, seq, ok := it.timeFromName(name, prefix, ext+cext); ok {
+				files = append(files, logInfo{t, seq, name, true})
+				break
+			}
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].timestamp.Equal(files[j].timestamp) {
+			return files[i].seq > files[j].seq
+		}
+		return files[i].timestamp.After(files[j].timestamp)
+	})
+	return files, nil
+}
+
+// timeFromName extracts the time and the sequence number encoded in the
+// name of a backup.
+func (it *rollingConfig) timeFromName(name, prefix, ext string) (time.Time, int, bool) {
+	if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) ||
+		len(name) < len(prefix)+len(ext) {
+		return time.Time{}, 0, false
+	}
+	stamp := name[len(prefix) : len(name)-len(ext)]
+	loc := time.UTC
+	if it.localTime {
+		loc = time.Local
+	}
+	if t, err := time.ParseInLocation(it.layout(), stamp, loc); err == nil {
+		return t, 0, true
+	}
+	i := strings.LastIndexByte(stamp, '.')
+	if i < 0 {
+		return time.Time{}, 0, false
+	}
+	seq, err := strconv.Atoi(stamp[i+1:])
+	if err != nil || seq <= 0 {
+		return time.Time{}, 0, false
+	}
+	t, err := time.ParseInLocation(it.layout(), stamp[:i], loc)
+	return t, seq, err == nil
+}
+
+// compressFile compresses src into dst and removes src.
+func (it *rollingConfig) compressFile(src, dst string) (err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("open log file %v, error: %v", src, err)
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return fmt.Errorf("get info of log file %v, error: %v", src, err)
+	}
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, info.Mode())
+	if err != nil {
+		return fmt.Errorf("open compressed log file %v, error: %v", dst, err)
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(dst)
+			err = fmt.Errorf("compress log file %v, error: %v", src, err)
+		}
+	}()
+
+	w, err := it.compressor.NewWriter(out, it.compressLevel)
+	if err != nil {
+		out.Close()
+		return
+	}
+	if _, err = io.Copy(w, in); err != nil {
+		w.Close()
+		out.Close()
+		return
+	}
+	if err = w.Close(); err != nil {
+		out.Close()
+		return
+	}
+	if err = out.Close(); err != nil {
+		return
+	}
+	in.Close()
+	return os.Remove(src)
+}
@@ -0,0 +1,164 @@
+// Copyright (c) 2018 souhup
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logx
+
+import (
+	"bytes"
+	"compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	. "gopkg.in/check.v1"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+func (it *MySuite) TestRotateBySize(c *C) {
+	dir := c.MkDir()
+	file := &rollingFile{rollingConfig: rollingConfig{filename: filepath.Join(dir, "test.log"), maxSize: 1}}
+	defer file.Close()
+
+	line := []byte(strings.Repeat("x", 1023) + "\n")
+	for i := 0; i < megabyte/len(line)+1; i++ {
+		_, err := file.Write(line)
+		c.Assert(err, IsNil)
+	}
+	files, err := file.oldLogFiles()
+	c.Assert(err, IsNil)
+	c.Assert(files, HasLen, 1)
+	c.Assert(files[0].compressed, Equals, false)
+}
+
+func (it *MySuite) TestRotateByTime(c *C) {
+	now := time.Date(2026, 10, 17, 13, 10, 0, 0, time.UTC)
+	clock.Store(func() time.Time { return now })
+	defer clock.Store(time.Now)
+
+	dir := c.MkDir()
+	file := &rollingFile{rollingConfig: rollingConfig{
+		filename:   filepath.Join(dir, "app.log"),
+		interval:   time.Hour,
+		align:      true,
+		timeFormat: "2006-01-02T15",
+	}}
+	defer file.Close()
+
+	for _, minutes := range []int{0, 40, 55} {
+		now = time.Date(2026, 10, 17, 13, 10+minutes, 0, 0, time.UTC)
+		_, err := file.Write([]byte("line\n"))
+		c.Assert(err, IsNil)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dir, "app-2026-10-17T13.log"))
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "line\nline\n")
+
+	// a size rotation within the period shares the name of the period.
+	c.Assert(file.rotate(file.backupTime()), IsNil)
+	now = now.Add(time.Hour)
+	_, err = file.Write([]byte("line\n"))
+	c.Assert(err, IsNil)
+	files, err := file.oldLogFiles()
+	c.Assert(err, IsNil)
+	c.Assert(files, HasLen, 3)
+	c.Assert(files[0].name, Equals, "app-2026-10-17T14.1.log")
+	c.Assert(files[1].name, Equals, "app-2026-10-17T14.log")
+	c.Assert(files[2].name, Equals, "app-2026-10-17T13.log")
+}
+
+func (it *MySuite) TestWriteAfterClose(c *C) {
+	file := &rollingFile{rollingConfig: rollingConfig{filename: filepath.Join(c.MkDir(), "test.log")}}
+	_, err := file.Write([]byte("line\n"))
+	c.Assert(err, IsNil)
+	c.Assert(file.Close(), IsNil)
+
+	_, err = file.Write([]byte("line\n"))
+	c.Assert(err, ErrorMatches, "write to closed log file .*test.log")
+	c.Assert(file.file, IsNil)
+}
+
+func (it *MySuite) TestCompressBackups(c *C) {
+	content := []byte("test compression\n")
+	for name, compressor := range map[string]Compressor{"gzip": gzipCompressor{}, "zstd": zstdCompressor{}} {
+		dir := c.MkDir()
+		file := &rollingFile{rollingConfig: rollingConfig{filename: filepath.Join(dir, "test.log"), compressor: compressor}}
+		backup := file.backupName(currentTime())
+		c.Assert(ioutil.WriteFile(backup, content, 0644), IsNil)
+
+		c.Assert(file.millRunOnce(), IsNil)
+		files, err := file.oldLogFiles()
+		c.Assert(err, IsNil)
+		c.Assert(files, HasLen, 1)
+		c.Assert(files[0].compressed, Equals, true)
+
+		data, err := ioutil.ReadFile(backup + compressor.Extension())
+		c.Assert(err, IsNil)
+		c.Assert(decompress(c, name, data), DeepEquals, content)
+	}
+}
+
+func (it *MySuite) TestMaxBackups(c *C) {
+	dir := c.MkDir()
+	file := &rollingFile{rollingConfig: rollingConfig{filename: filepath.Join(dir, "test.log"), maxBackups: 2}}
+	now := currentTime()
+	for i := 0; i < 4; i++ {
+		backup := file.backupName(now.Add(-time.Duration(i) * time.Hour))
+		c.Assert(ioutil.WriteFile(backup, nil, 0644), IsNil)
+	}
+
+	c.Assert(file.millRunOnce(), IsNil)
+	files, err := file.oldLogFiles()
+	c.Assert(err, IsNil)
+	c.Assert(files, HasLen, 2)
+}
+
+func (it *MySuite) TestUnknownCompression(c *C) {
+	filename := filepath.Join(c.MkDir(), "test.log")
+	_, err := GetLoggerByConf(&Config{MessageKey: "msg", Encoding: "json", Filename: filename, Compression: "lz4"})
+	c.Assert(err, NotNil)
+}
+
+func (it *MySuite) TestCompressionLevel(c *C) {
+	filename := filepath.Join(c.MkDir(), "test.log")
+	config := &Config{MessageKey: "msg", Encoding: "json", Filename: filename, Compress: true, CompressionLevel: 20}
+	c.Assert(config.Validate(), ErrorMatches, "compression_level is invalid for gzip, but got 20, error: .*")
+	config.CompressionLevel = 9
+	c.Assert(config.Validate(), IsNil)
+	config.Compression, config.CompressionLevel = "zstd", 20
+	c.Assert(config.Validate(), IsNil)
+}
+
+func decompress(c *C, name string, data []byte) []byte {
+	var out []byte
+	var err error
+	switch name {
+	case "gzip":
+		r, e := gzip.NewReader(bytes.NewReader(data))
+		c.Assert(e, IsNil)
+		out, err = ioutil.ReadAll(r)
+	case "zstd":
+		r, e := zstd.NewReader(bytes.NewReader(data))
+		c.Assert(e, IsNil)
+		defer r.Close()
+		out, err = ioutil.ReadAll(r)
+	}
+	c.Assert(err, IsNil)
+	return out
+}
@@ -0,0 +1,156 @@
+// Copyright (c) 2018 souhup
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logx
+
+import (
+	"go.uber.org/zap/zapcore"
+	"sync/atomic"
+	"time"
+)
+
+// Sampling limits the entries of the same level and message logged in each
+// tick: the first Initial entries are logged, and then every Thereafter-th
+// one. The entries sampled away are counted by Logger.Stats.
+type Sampling struct {
+	// Initial is the number of entries logged first in each tick.
+	Initial int `yaml:"initial"`
+
+	// Thereafter is the rate of entries logged after Initial, such as 100
+	// for one in a hundred. The default 0 is to log none of them.
+	Thereafter int `yaml:"thereafter"`
+
+	// Tick is the period of counting, and the default is 1s.
+	Tick time.Duration `yaml:"tick"`
+
+	// Levels override Initial and Thereafter for the levels.
+	Levels map[Level]SamplingRate `yaml:"levels"`
+}
+
+// SamplingRate is Initial and Thereafter of Sampling for a level.
+type SamplingRate struct {
+	Initial    int `yaml:"initial"`
+	Thereafter int `yaml:"thereafter"`
+}
+
+const (
+	// defaultSamplingTick is the tick of Sampling if it is not set.
+	defaultSamplingTick = time.Second
+
+	// samplerBuckets is the number of counters of each level. Messages are
+	// hashed to the counters, so different messages may share one.
+	samplerBuckets = 1024
+
+	// samplerLevels is the number of levels from debug to fatal.
+	samplerLevels = int(FatalLevel-DebugLevel) + 1
+)
+
+// samplerCore is a zapcore.Core which drops the entries sampled away, and
+// writes the others to the wrapped core.
+type samplerCore struct {
+	zapcore.Core
+	rates   [samplerLevels]SamplingRate
+	tick    time.Duration
+	counts  *[samplerLevels][samplerBuckets]samplerCounter
+	sampled *uint64
+}
+
+// samplerCounter counts the entries hashed to it in the current tick.
+type samplerCounter struct {
+	resetAt int64
+	count   uint64
+}
+
+// newSamplerCore wraps core by sampling. The entries sampled away are
+// counted by sampled.
+func newSamplerCore(core zapcore.Core, sampling *Sampling, sampled *uint64) zapcore.Core {
+	it := &samplerCore{
+		Core:    core,
+		tick:    sampling.Tick,
+		counts:  new([samplerLevels][samplerBuckets]samplerCounter),
+		sampled: sampled,
+	}
+	if it.tick == 0 {
+		it.tick = defaultSamplingTick
+	}
+	for i := range it.rates {
+		it.rates[i] = SamplingRate{Initial: sampling.Initial, Thereafter: sampling.Thereafter}
+	}
+	for level, rate := range sampling.Levels {
+		it.rates[level-DebugLevel] = rate
+	}
+	return it
+}
+
+func (it *samplerCore) With(fields []zapcore.Field) zapcore.Core {
+	return &samplerCore{
+		Core:    it.Core.With(fields),
+		rates:   it.rates,
+		tick:    it.tick,
+		counts:  it.counts,
+		sampled: it.sampled,
+	}
+}
+
+func (it *samplerCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !it.Enabled(entry.Level) {
+		return ce
+	}
+	index := int(entry.Level - zapcore.DebugLevel)
+	if index < 0 || index >= samplerLevels {
+		return it.Core.Check(entry, ce)
+	}
+
+	rate := it.rates[index]
+	counter := &it.counts[index][fnv32a(entry.Message)%samplerBuckets]
+	n := counter.inc(entry.Time, it.tick)
+	if n > uint64(rate.Initial) && (rate.Thereafter == 0 || (n-uint64(rate.Initial))%uint64(rate.Thereafter) != 0) {
+		atomic.AddUint64(it.sampled, 1)
+		return ce
+	}
+	return it.Core.Check(entry, ce)
+}
+
+// inc counts an entry at t, and returns the count in the tick of t.
+func (it *samplerCounter) inc(t time.Time, tick time.Duration) uint64 {
+	now := t.UnixNano()
+	resetAt := atomic.LoadInt64(&it.resetAt)
+	if resetAt > now {
+		return atomic.AddUint64(&it.count, 1)
+	}
+
+	atomic.StoreUint64(&it.count, 1)
+	if !atomic.CompareAndSwapInt64(&it.resetAt, resetAt, now+tick.Nanoseconds()) {
+		// another entry has started the tick.
+		return atomic.AddUint64(&it.count, 1)
+	}
+	return 1
+}
+
+// fnv32a hashes s by FNV-1a without allocating.
+func fnv32a(s string) uint32 {
+	const offset32, prime32 = 2166136261, 16777619
+	hash := uint32(offset32)
+	for i := 0; i < len(s); i++ {
+		hash ^= uint32(s[i])
+		hash *= prime32
+	}
+	return hash
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2018 souhup
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logx
+
+import (
+	. "gopkg.in/check.v1"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+func (it *MySuite) TestSampling(c *C) {
+	filename := filepath.Join(c.MkDir(), "sampling.log")
+	logger, err := GetLoggerByConf(&Config{
+		MessageKey: "msg",
+		LevelKey:   "level",
+		Encoding:   "console",
+		Filename:   filename,
+		Sampling: &Sampling{
+			Initial:    2,
+			Thereafter: 3,
+			Tick:       time.Hour,
+			Levels:     map[Level]SamplingRate{ErrorLevel: {Initial: 100}},
+		},
+	})
+	c.Assert(err, IsNil)
+
+	for i := 0; i < 10; i++ {
+		logger.With("i", i).Info("hot")
+		logger.Error("failed")
+	}
+	logger.Warn("hot")
+	logger.Flush()
+
+	data, err := ioutil.ReadFile(filename)
+	c.Assert(err, IsNil)
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		if !strings.HasPrefix(line, "ERROR") {
+			lines = append(lines, line)
+		}
+	}
+	c.Assert(lines, DeepEquals, []string{
+		"INFO\thot\t{\"i\": 0}",
+		"INFO\thot\t{\"i\": 1}",
+		"INFO\thot\t{\"i\": 4}",
+		"INFO\thot\t{\"i\": 7}",
+		"WARN\thot",
+	})
+	c.Assert(strings.Count(string(data), "ERROR"), Equals, 10)
+	c.Assert(logger.Stats().Sampled, Equals, uint64(6))
+}
+
+func (it *MySuite) TestSamplingTick(c *C) {
+	filename := filepath.Join(c.MkDir(), "tick.log")
+	logger, err := GetLoggerByConf(&Config{
+		MessageKey: "msg",
+		Encoding:   "json",
+		Filename:   filename,
+		Sampling:   &Sampling{Initial: 1, Tick: 20 * time.Millisecond},
+	})
+	c.Assert(err, IsNil)
+
+	logger.Info("hot")
+	logger.Info("hot")
+	time.Sleep(40 * time.Millisecond)
+	logger.Info("hot")
+	logger.Flush()
+
+	data, err := ioutil.ReadFile(filename)
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, strings.Repeat(`{"msg":"hot"}`+"\n", 2))
+	c.Assert(logger.Stats().Sampled, Equals, uint64(1))
+}
+
+func (it *MySuite) TestSamplingConfig(c *C) {
+	config, err := parseConfig("sampling", "yaml", []byte("sampling:\n  initial: 100\n  thereafter: 100\n  tick: 1s\n"+
+		"  levels:\n    error: {initial: 1000, thereafter: 10}\n"))
+	c.Assert(err, IsNil)
+	c.Assert(config.Sampling, DeepEquals, &Sampling{
+		Initial:    100,
+		Thereafter: 100,
+		Tick:       time.Second,
+		Levels:     map[Level]SamplingRate{ErrorLevel: {Initial: 1000, Thereafter: 10}},
+	})
+
+	config = &Config{MessageKey: "msg", Encoding: "json", Sampling: &Sampling{
+		Thereafter: -1,
+		Tick:       -time.Second,
+		Levels:     map[Level]SamplingRate{WarnLevel: {Initial: 0}, 7: {Initial: 1}},
+	}}
+	c.Assert(config.Validate(), ErrorMatches, "sampling.initial must be positive, but got 0; "+
+		"sampling.thereafter must not be negative, but got -1; "+
+		"sampling.tick must not be negative, but got -1s; "+
+		"sampling.levels.warn.initial must be positive, but got 0; "+
+		"sampling.levels.Level\\(7\\) must be one of the debug, info, warn, error, dpanic, panic or fatal, but got 7")
+}
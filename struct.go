@@ -0,0 +1,383 @@
+// Copyright (c) 2018 souhup
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logx
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Struct constructs a field with a struct, or a pointer to one, encoded as a
+// nested object. Fields are named by their json tags or their names, fields
+// tagged `json:"-"` are omitted, and the logx tags change them:
+//
+//	Password string `logx:"redact"`      // written as ***
+//	Session  string `logx:"-"`           // omitted
+//	UserID   int    `logx:"name=user_id"` // named user_id
+//
+// Options are separated by commas, such as `logx:"name=card,redact"`.
+// Unexported fields are omitted. Other values are constructed by Any.
+func Struct(key string, value interface{}) Field {
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return Any(key, value)
+	}
+	return zap.Object(key, structMarshaler{value: v})
+}
+
+// maxStructDepth is the maximum nesting of structs, which stops cycles of
+// pointers.
+const maxStructDepth = 32
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	objectType   = reflect.TypeOf((*zapcore.ObjectMarshaler)(nil)).Elem()
+	arrayType    = reflect.TypeOf((*zapcore.ArrayMarshaler)(nil)).Elem()
+	jsonType     = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textType     = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// structField is the metadata of a field of a struct.
+type structField struct {
+	index  int
+	name   string
+	redact bool
+}
+
+// structFields caches the fields of struct types by reflect.Type.
+var structFields sync.Map
+
+// fieldsOf returns the fields of the struct type t to encode.
+func fieldsOf(t reflect.Type) []structField {
+	if cached, ok := structFields.Load(t); ok {
+		return cached.([]structField)
+	}
+	fields := make([]structField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		// fields hidden from json are hidden from logs too.
+		jsonTag := field.Tag.Get("json")
+		if jsonTag == "-" {
+			continue
+		}
+		info := structField{index: i, name: field.Name}
+		if name := strings.Split(jsonTag, ",")[0]; name != "" {
+			info.name = name
+		}
+		tag := field.Tag.Get("logx")
+		if tag == "-" {
+			continue
+		}
+		for _, option := range strings.Split(tag, ",") {
+			switch {
+			case option == "redact":
+				info.redact = true
+			case strings.HasPrefix(option, "name="):
+				info.name = strings.TrimPrefix(option, "name=")
+			}
+		}
+		fields = append(fields, info)
+	}
+	cached, _ := structFields.LoadOrStore(t, fields)
+	return cached.([]structField)
+}
+
+// structMarshaler encodes a struct by the metadata of its fields.
+type structMarshaler struct {
+	value reflect.Value
+	depth int
+}
+
+func (it structMarshaler) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	if it.depth >= maxStructDepth {
+		return fmt.Errorf("struct %v is nested more than %d levels", it.value.Type(), maxStructDepth)
+	}
+	for _, field := range fieldsOf(it.value.Type()) {
+		if field.redact {
+			enc.AddString(field.name, maskedValue)
+			continue
+		}
+		if err := addValue(enc, field.name, it.value.Field(field.index), it.depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sliceMarshaler encodes a slice or an array by its elements.
+type sliceMarshaler struct {
+	value reflect.Value
+	depth int
+}
+
+func (it sliceMarshaler) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for i := 0; i < it.value.Len(); i++ {
+		if err := appendValue(enc, it.value.Index(i), it.depth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mapMarshaler encodes a map by its entries, sorted by their keys.
+type mapMarshaler struct {
+	value reflect.Value
+	depth int
+}
+
+func (it mapMarshaler) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	if it.depth >= maxStructDepth {
+		return fmt.Errorf("map %v is nested more than %d levels", it.value.Type(), maxStructDepth)
+	}
+	keys := it.value.MapKeys()
+	names := make([]string, len(keys))
+	for i, key := range keys {
+		names[i] = fmt.Sprint(key.Interface())
+	}
+	sort.Sort(mapKeys{keys, names})
+	for i, key := range keys {
+		if err := addValue(enc, names[i], it.value.MapIndex(key), it.depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mapKeys sorts the keys of a map by their names.
+type mapKeys struct {
+	keys  []reflect.Value
+	names []string
+}
+
+func (it mapKeys) Len() int           { return len(it.keys) }
+func (it mapKeys) Less(i, j int) bool { return it.names[i] < it.names[j] }
+func (it mapKeys) Swap(i, j int) {
+	it.keys[i], it.keys[j] = it.keys[j], it.keys[i]
+	it.names[i], it.names[j] = it.names[j], it.names[i]
+}
+
+// marshalerKind tells how a value encodes itself.
+type marshalerKind int
+
+const (
+	noMarshaler marshalerKind = iota
+	objectMarshaler
+	arrayMarshaler
+	jsonMarshaler // json.Marshaler or encoding.TextMarshaler, encoded by json
+)
+
+// marshalerOf returns how v encodes itself. Times and durations are encoded
+// like the fields of zap instead.
+func marshalerOf(v reflect.Value) marshalerKind {
+	if !v.CanInterface() {
+		return noMarshaler
+	}
+	switch t := v.Type(); {
+	case t == timeType || t == durationType:
+		return noMarshaler
+	case t.Implements(objectType):
+		return objectMarshaler
+	case t.Implements(arrayType):
+		return arrayMarshaler
+	case t.Implements(jsonType) || t.Implements(textType):
+		return jsonMarshaler
+	}
+	return noMarshaler
+}
+
+// addValue adds v to enc under key. Marshalers encode themselves, and
+// structs, maps and slices are encoded by their elements, so that the logx
+// tags of nested structs are honored.
+func addValue(enc zapcore.ObjectEncoder, key string, v reflect.Value, depth int) error {
+	for {
+		if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() || !v.IsValid() {
+			return enc.AddReflected(key, nil)
+		}
+		switch marshalerOf(v) {
+		case objectMarshaler:
+			return enc.AddObject(key, v.Interface().(zapcore.ObjectMarshaler))
+		case arrayMarshaler:
+			return enc.AddArray(key, v.Interface().(zapcore.ArrayMarshaler))
+		case jsonMarshaler:
+			return enc.AddReflected(key, v.Interface())
+		}
+		if v.Kind() != reflect.Ptr && v.Kind() != reflect.Interface {
+			break
+		}
+		v = v.Elem()
+	}
+	switch v.Type() {
+	case timeType:
+		enc.AddTime(key, v.Interface().(time.Time))
+		return nil
+	case durationType:
+		enc.AddDuration(key, time.Duration(v.Int()))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		enc.AddBool(key, v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		enc.AddInt64(key, v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		enc.AddUint64(key, v.Uint())
+	case reflect.Float32, reflect.Float64:
+		enc.AddFloat64(key, v.Float())
+	case reflect.String:
+		enc.AddString(key, v.String())
+	case reflect.Struct:
+		return enc.AddObject(key, structMarshaler{value: v, depth: depth})
+	case reflect.Map:
+		return enc.AddObject(key, mapMarshaler{value: v, depth: depth})
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			enc.AddBinary(key, v.Bytes())
+			return nil
+		}
+		return enc.AddArray(key, sliceMarshaler{value: v, depth: depth})
+	default:
+		return enc.AddReflected(key, v.Interface())
+	}
+	return nil
+}
+
+// appendValue is same as addValue, but appends v to an array.
+func appendValue(enc zapcore.ArrayEncoder, v reflect.Value, depth int) error {
+	for {
+		if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() || !v.IsValid() {
+			return enc.AppendReflected(nil)
+		}
+		switch marshalerOf(v) {
+		case objectMarshaler:
+			return enc.AppendObject(v.Interface().(zapcore.ObjectMarshaler))
+		case arrayMarshaler:
+			return enc.AppendArray(v.Interface().(zapcore.ArrayMarshaler))
+		case jsonMarshaler:
+			return enc.AppendReflected(v.Interface())
+		}
+		if v.Kind() != reflect.Ptr && v.Kind() != reflect.Interface {
+			break
+		}
+		v = v.Elem()
+	}
+	switch v.Type() {
+	case timeType:
+		enc.AppendTime(v.Interface().(time.Time))
+		return nil
+	case durationType:
+		enc.AppendDuration(time.Duration(v.Int()))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		enc.AppendBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		enc.AppendInt64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		enc.AppendUint64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		enc.AppendFloat64(v.Float())
+	case reflect.String:
+		enc.AppendString(v.String())
+	case reflect.Struct:
+		return enc.AppendObject(structMarshaler{value: v, depth: depth})
+	case reflect.Map:
+		return enc.AppendObject(mapMarshaler{value: v, depth: depth})
+	case reflect.Slice, reflect.Array:
+		return enc.AppendArray(sliceMarshaler{value: v, depth: depth})
+	default:
+		return enc.AppendReflected(v.Interface())
+	}
+	return nil
+}
+
+// objectArg returns the field of a non-primitive argument of Debug, Info and
+// the others, named by its type such as user for User, or by its kind such as
+// map. Marshalers, structs, maps and slices are fields, while errors and
+// Stringers, such as time.Time, are written in the message unless they are
+// ObjectMarshalers or ArrayMarshalers.
+func objectArg(arg interface{}) (Field, bool) {
+	if arg == nil {
+		return Field{}, false
+	}
+	v := reflect.ValueOf(arg)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Ptr {
+		return Field{}, false
+	}
+	key := argKey(v.Type())
+	switch arg := arg.(type) {
+	case zapcore.ObjectMarshaler:
+		return zap.Object(key, arg), true
+	case zapcore.ArrayMarshaler:
+		return zap.Array(key, arg), true
+	case error, fmt.Stringer:
+		return Field{}, false
+	case json.Marshaler:
+		return zap.Reflect(key, arg), true
+	case encoding.TextMarshaler:
+		return zap.Reflect(key, arg), true
+	case []byte:
+		return zap.Binary(key, arg), true
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		return zap.Object(key, structMarshaler{value: v}), true
+	case reflect.Map:
+		return zap.Object(key, mapMarshaler{value: v}), true
+	case reflect.Slice, reflect.Array:
+		return zap.Array(key, sliceMarshaler{value: v}), true
+	}
+	return Field{}, false
+}
+
+// argKey names an argument by its type, or by its kind if the type is
+// unnamed.
+func argKey(t reflect.Type) string {
+	name := t.Name()
+	if name == "" {
+		return t.Kind().String()
+	}
+	r, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToLower(r)) + name[size:]
+}
@@ -0,0 +1,155 @@
+// Copyright (c) 2018 souhup
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logx
+
+import (
+	"errors"
+	. "gopkg.in/check.v1"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type testAddress struct {
+	City string `json:"city"`
+	Zip  string `logx:"redact"`
+}
+
+type testUser struct {
+	ID        int           `logx:"name=user_id"`
+	Name      string        `json:"name,omitempty"`
+	Password  string        `logx:"redact"`
+	Session   string        `logx:"-"`
+	Card      string        `logx:"name=card,redact"`
+	Timeout   time.Duration `json:"timeout"`
+	Addresses []testAddress `json:"addresses"`
+	Manager   *testUser     `json:"manager"`
+	APIKey    string        `json:"-"`
+	Dash      string        `json:"-,"`
+	secret    string
+}
+
+type testNode struct {
+	Next *testNode
+}
+
+func (it *MySuite) TestStruct(c *C) {
+	filename := filepath.Join(c.MkDir(), "struct.log")
+	logger, err := GetLoggerByConf(&Config{MessageKey: "msg", Encoding: "json", Filename: filename})
+	c.Assert(err, IsNil)
+
+	user := testUser{
+		ID:        7,
+		Name:      "bob",
+		Password:  "hunter2",
+		Session:   "abc",
+		Card:      "4111",
+		Timeout:   time.Second,
+		Addresses: []testAddress{{City: "Paris", Zip: "75001"}},
+		APIKey:    "key",
+		Dash:      "dash",
+		secret:    "hidden",
+	}
+	logger.Info("created", &user, "by", errors.New("admin"))
+	logger.InfoFields("fields", Struct("user", user), Struct("id", 7))
+	node := &testNode{}
+	node.Next = node
+	logger.Info("cycle", node)
+	logger.Flush()
+
+	data, err := ioutil.ReadFile(filename)
+	c.Assert(err, IsNil)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	object := `{"user_id":7,"name":"bob","Password":"***","card":"***","timeout":1,` +
+		`"addresses":[{"city":"Paris","Zip":"***"}],"manager":null,"-":"dash"}`
+	c.Assert(lines[0], Equals, `{"msg":"created by admin","testUser":`+object+`}`)
+	c.Assert(lines[1], Equals, `{"msg":"fields","user":`+object+`,"id":7}`)
+	// cycles are cut, and reported like errors of zap.
+	c.Assert(strings.Count(lines[2], `"Next"`), Equals, maxStructDepth)
+	c.Assert(strings.HasSuffix(lines[2], `"testNodeError":"struct logx.testNode is nested more than 32 levels"}`), Equals, true)
+}
+
+type testJSON struct{}
+
+func (testJSON) MarshalJSON() ([]byte, error) {
+	return []byte(`{"raw":true}`), nil
+}
+
+type testText struct{}
+
+func (*testText) MarshalText() ([]byte, error) {
+	return []byte("text"), nil
+}
+
+type testObject struct{}
+
+func (testObject) MarshalLogObject(enc ObjectEncoder) error {
+	enc.AddString("by", "zap")
+	return nil
+}
+
+func (testObject) String() string {
+	return "object"
+}
+
+func (it *MySuite) TestObjectArgs(c *C) {
+	dir := c.MkDir()
+	for _, t := range []struct {
+		encoding string
+		line     string
+	}{
+		{"json", `{"msg":"args 1 a 2026-10-17 13:10:00 +0000 UTC","map":{"a":{"city":"Paris","Zip":"***"},"b":[1,2]},` +
+			`"slice":[{"raw":true},"text"],"testObject":{"by":"zap"},"map_2":{"1":null}}`},
+		{"console", `args 1 a 2026-10-17 13:10:00 +0000 UTC	{"map": {"a": {"city": "Paris", "Zip": "***"}, "b": [1, 2]}, ` +
+			`"slice": [{"raw":true}, "text"], "testObject": {"by": "zap"}, "map_2": {"1": null}}`},
+	} {
+		filename := filepath.Join(dir, t.encoding+".log")
+		logger, err := GetLoggerByConf(&Config{MessageKey: "msg", Encoding: t.encoding, Filename: filename})
+		c.Assert(err, IsNil)
+
+		logger.Info("args", 1, map[string]interface{}{
+			"b": []int{1, 2},
+			"a": testAddress{City: "Paris", Zip: "75001"},
+		}, []interface{}{testJSON{}, &testText{}}, "a", testObject{}, map[int]*testUser{1: nil},
+			time.Date(2026, 10, 17, 13, 10, 0, 0, time.UTC))
+		logger.Flush()
+
+		data, err := ioutil.ReadFile(filename)
+		c.Assert(err, IsNil)
+		c.Assert(string(data), Equals, t.line+"\n")
+	}
+}
+
+// TestObjectArgKeys keeps the keys of objects apart from the keys of the
+// encoder and of the fields added by With.
+func (it *MySuite) TestObjectArgKeys(c *C) {
+	filename := filepath.Join(c.MkDir(), "keys.log")
+	logger, err := GetLoggerByConf(&Config{MessageKey: "slice", Encoding: "json", Filename: filename})
+	c.Assert(err, IsNil)
+
+	logger.With("map", 1).Info("args", map[string]int{"a": 1}, []int{2}, map[string]int{"b": 3})
+	logger.Flush()
+
+	data, err := ioutil.ReadFile(filename)
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, `{"slice":"args","map":1,"map_2":{"a":1},"slice_2":[2],"map_3":{"b":3}}`+"\n")
+}
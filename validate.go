@@ -0,0 +1,274 @@
+// Copyright (c) 2018 souhup
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logx
+
+import (
+	"fmt"
+	"go.uber.org/multierr"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Validate checks Config and returns all problems found together. The
+// problems can be listed by multierr.Errors.
+func (it *Config) Validate() (err error) {
+	err = multierr.Append(err, validateLevel("level", it.Level))
+	names := make([]string, 0, len(it.Modules))
+	for name := range it.Modules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if name == "" {
+			err = multierr.Append(err, fmt.Errorf("name of modules must not be empty"))
+			continue
+		}
+		err = multierr.Append(err, validateLevel("modules."+name, it.Modules[name]))
+	}
+
+	err = multierr.Append(err, validateAsync(it))
+	if it.Sampling != nil {
+		err = multierr.Append(err, validateSampling(it.Sampling))
+	}
+	if it.DedupWindow < 0 {
+		err = multierr.Append(err, fmt.Errorf("dedup_window must not be negative, but got %v", it.DedupWindow))
+	}
+	if it.Redact != nil {
+		err = multierr.Append(err, validateRedact(it.Redact))
+	}
+
+	// the encoding is only used by sinks without their own.
+	useEncoding := len(it.Sinks) == 0
+	for _, sink := range it.Sinks {
+		useEncoding = useEncoding || sink.Encoding == ""
+	}
+	if useEncoding {
+		err = multierr.Append(err, validateEncoding("encoding", it.Encoding, it.MessageKey))
+	}
+
+	if len(it.Sinks) == 0 {
+		if it.Filename != "" {
+			err = multierr.Append(err, validateFile("file_name", it.Filename))
+		}
+		return multierr.Append(err, validateRotation("", it.rotation()))
+	}
+
+	// sinks of the same file share it, and so its rotation.
+	rotations := make(map[string]int)
+	for i, sink := range it.Sinks {
+		prefix := fmt.Sprintf("sinks[%d].", i)
+		if sink.Level != nil {
+			err = multierr.Append(err, validateLevel(prefix+"level", *sink.Level))
+		}
+		if sink.Encoding != "" {
+			err = multierr.Append(err, validateEncoding(prefix+"encoding", sink.Encoding, it.MessageKey))
+		}
+		switch sink.Output {
+		case "stdout", "stderr":
+		case "":
+			err = multierr.Append(err, fmt.Errorf("%soutput must not be empty", prefix))
+		default:
+			if first, ok := rotations[sink.Output]; !ok {
+				rotations[sink.Output] = i
+			} else if it.Sinks[first].Rotation != sink.Rotation {
+				err = multierr.Append(err, fmt.Errorf("%srotation must be the same as sinks[%d] writing to %v", prefix, first, sink.Output))
+			}
+			err = multierr.Append(err, validateFile(prefix+"output", sink.Output))
+			err = multierr.Append(err, validateRotation(prefix, sink.Rotation))
+		}
+	}
+	return
+}
+
+func validateLevel(key string, level Level) error {
+	if level < DebugLevel || level > FatalLevel {
+		return fmt.Errorf("%v must be one of the debug, info, warn, error, dpanic, panic or fatal, but got %d", key, level)
+	}
+	return nil
+}
+
+func validateEncoding(key, encoding, messageKey string) error {
+	switch encoding {
+	case "json":
+		if messageKey == "" {
+			return fmt.Errorf("message_key must not be empty with json encoding of %v", key)
+		}
+	case "console":
+	default:
+		return fmt.Errorf("%v must be one of the json or console, but got %q", key, encoding)
+	}
+	return nil
+}
+
+// validateAsync checks the options of writing in the background.
+func validateAsync(config *Config) (err error) {
+	if config.BufferSize < 0 {
+		err = multierr.Append(err, fmt.Errorf("buffer_size must not be negative, but got %d", config.BufferSize))
+	}
+	if config.FlushInterval < 0 {
+		err = multierr.Append(err, fmt.Errorf("flush_interval must not be negative, but got %v", config.FlushInterval))
+	}
+	switch config.Overflow {
+	case "", overflowBlock, overflowDropNewest, overflowDropOldest:
+	default:
+		err = multierr.Append(err, fmt.Errorf("overflow must be one of the block, drop_newest or drop_oldest, but got %q", config.Overflow))
+	}
+	return
+}
+
+// validateSampling checks the rates and the tick of sampling.
+func validateSampling(sampling *Sampling) (err error) {
+	err = multierr.Append(err, validateSamplingRate("sampling.", SamplingRate{sampling.Initial, sampling.Thereafter}))
+	if sampling.Tick < 0 {
+		err = multierr.Append(err, fmt.Errorf("sampling.tick must not be negative, but got %v", sampling.Tick))
+	}
+	levels := make([]Level, 0, len(sampling.Levels))
+	for level := range sampling.Levels {
+		levels = append(levels, level)
+	}
+	sort.Slice(levels, func(i, j int) bool { return levels[i] < levels[j] })
+	for _, level := range levels {
+		prefix := fmt.Sprintf("sampling.levels.%v.", level)
+		if e := validateLevel(prefix[:len(prefix)-1], level); e != nil {
+			err = multierr.Append(err, e)
+			continue
+		}
+		err = multierr.Append(err, validateSamplingRate(prefix, sampling.Levels[level]))
+	}
+	return
+}
+
+func validateSamplingRate(prefix string, rate SamplingRate) (err error) {
+	if rate.Initial <= 0 {
+		err = multierr.Append(err, fmt.Errorf("%sinitial must be positive, but got %d", prefix, rate.Initial))
+	}
+	if rate.Thereafter < 0 {
+		err = multierr.Append(err, fmt.Errorf("%sthereafter must not be negative, but got %d", prefix, rate.Thereafter))
+	}
+	return
+}
+
+// validateRedact checks the keys, the patterns and the strategy of redact.
+func validateRedact(redact *Redact) (err error) {
+	for _, key := range redact.Keys {
+		if _, e := path.Match(key, ""); e != nil || key == "" {
+			err = multierr.Append(err, fmt.Errorf("redact.keys must be glob patterns, but got %q", key))
+		}
+	}
+	for _, pattern := range redact.Patterns {
+		if _, e := compilePattern(pattern); e != nil {
+			err = multierr.Append(err, fmt.Errorf("redact.patterns must be credit_card, bearer_token, email or regular expressions, but got %q, error: %v", pattern, e))
+		}
+	}
+	switch redact.Strategy {
+	case "", redactMask, redactDrop:
+	case redactHash:
+		if redact.HashKey == "" {
+			err = multierr.Append(err, fmt.Errorf("redact.hash_key must not be empty for the strategy hash"))
+		}
+	default:
+		err = multierr.Append(err, fmt.Errorf("redact.strategy must be one of the mask, hash or drop, but got %q", redact.Strategy))
+	}
+	return
+}
+
+// validateFile checks that the directory of the file can be written. If the
+// directory does not exist yet, the directory it will be created in is
+// checked.
+func validateFile(key, filename string) error {
+	dir := filepath.Dir(filename)
+	for {
+		info, err := os.Stat(dir)
+		if os.IsNotExist(err) && filepath.Dir(dir) != dir {
+			dir = filepath.Dir(dir)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("%v %v is not writable, error: %v", key, filename, err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("%v %v is not writable, %v is not a directory", key, filename, dir)
+		}
+		break
+	}
+
+	probe, err := ioutil.TempFile(dir, ".logx-")
+	if err != nil {
+		return fmt.Errorf("%v %v is not writable, error: %v", key, filename, err)
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+	return nil
+}
+
+// validateRotation checks the rotation of a file, and prefix is prepended
+// to the keys in errors.
+func validateRotation(prefix string, rotation Rotation) (err error) {
+	counts := []struct {
+		key   string
+		value int
+	}{
+		{"max_size", rotation.MaxSize},
+		{"max_age", rotation.MaxAge},
+		{"max_backups", rotation.MaxBackups},
+	}
+	for _, count := range counts {
+		if count.value < 0 {
+			err = multierr.Append(err, fmt.Errorf("%s%v must not be negative, but got %d", prefix, count.key, count.value))
+		}
+	}
+	if rotation.RotateInterval < 0 {
+		err = multierr.Append(err, fmt.Errorf("%srotate_interval must not be negative, but got %v", prefix, rotation.RotateInterval))
+	}
+	if rotation.RotateAlign && rotation.RotateInterval == 0 {
+		err = multierr.Append(err, fmt.Errorf("%srotate_align needs rotate_interval", prefix))
+	}
+	if rotation.BackupTimeFormat != "" {
+		layout := rotation.BackupTimeFormat
+		sample := time.Date(2017, 11, 23, 21, 37, 49, 0, time.UTC)
+		reference := time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)
+		if sample.Format(layout) == layout {
+			err = multierr.Append(err, fmt.Errorf("%sbackup_time_format %q contains no time", prefix, layout))
+		} else if _, e := time.Parse(layout, reference.Format(layout)); e != nil {
+			err = multierr.Append(err, fmt.Errorf("%sbackup_time_format %q can not be parsed back, error: %v", prefix, layout, e))
+		}
+	}
+	compression := rotation.Compression
+	if compression == "" && rotation.Compress {
+		compression = "gzip"
+	}
+	compressor, e := getCompressor(compression)
+	if e != nil {
+		err = multierr.Append(err, fmt.Errorf("%scompression must be one of the gzip, zstd, none or a registered name, but got %q", prefix, rotation.Compression))
+	} else if compressor != nil && rotation.CompressionLevel != 0 {
+		// the level is checked by the algorithm itself, e.g. -2 to 9 for gzip.
+		if w, e := compressor.NewWriter(ioutil.Discard, rotation.CompressionLevel); e != nil {
+			err = multierr.Append(err, fmt.Errorf("%scompression_level is invalid for %v, but got %d, error: %v", prefix, compression, rotation.CompressionLevel, e))
+		} else {
+			w.Close()
+		}
+	}
+	return
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2018 souhup
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logx
+
+import (
+	"go.uber.org/multierr"
+	. "gopkg.in/check.v1"
+	"io/ioutil"
+	"path/filepath"
+)
+
+func (it *MySuite) TestValidate(c *C) {
+	dir := c.MkDir()
+	notDir := filepath.Join(dir, "file")
+	c.Assert(ioutil.WriteFile(notDir, nil, 0644), IsNil)
+
+	conf := Config{
+		Level:            Level(7),
+		Encoding:         "json",
+		Filename:         filepath.Join(notDir, "test.log"),
+		MaxSize:          -1,
+		BackupTimeFormat: "12",
+	}
+	errs := multierr.Errors(conf.Validate())
+	c.Assert(errs, HasLen, 5)
+	c.Assert(errs[0], ErrorMatches, "level must be one of the .*, but got 7")
+	c.Assert(errs[1], ErrorMatches, "message_key must not be empty with json encoding of encoding")
+	c.Assert(errs[2], ErrorMatches, "file_name .* is not writable, .* is not a directory")
+	c.Assert(errs[3], ErrorMatches, "max_size must not be negative, but got -1")
+	c.Assert(errs[4], ErrorMatches, `backup_time_format "12" can not be parsed back, .*`)
+
+	conf = Config{
+		Sinks: []Sink{
+			{Output: "stdout", Encoding: "console"},
+			{Output: filepath.Join(dir, "logs", "test.log"), Encoding: "xml"},
+			{},
+		},
+	}
+	errs = multierr.Errors(conf.Validate())
+	c.Assert(errs, HasLen, 3)
+	c.Assert(errs[0], ErrorMatches, `encoding must be one of the json or console, but got ""`)
+	c.Assert(errs[1], ErrorMatches, `sinks\[1\].encoding must be one of the json or console, but got "xml"`)
+	c.Assert(errs[2], ErrorMatches, `sinks\[2\].output must not be empty`)
+
+	// sinks of the same file must rotate it the same way.
+	shared := filepath.Join(dir, "shared.log")
+	conf = Config{
+		MessageKey: "msg",
+		Encoding:   "json",
+		Sinks: []Sink{
+			{Output: shared, Rotation: Rotation{MaxSize: 1}},
+			{Output: shared, Rotation: Rotation{MaxSize: 1}, Encoding: "console"},
+			{Output: shared, Rotation: Rotation{MaxSize: 2}},
+		},
+	}
+	c.Assert(conf.Validate(), ErrorMatches, `sinks\[2\].rotation must be the same as sinks\[0\] writing to .*shared.log`)
+}
+
+func (it *MySuite) TestGetLoggerStrict(c *C) {
+	path := filepath.Join(c.MkDir(), "logs.yml")
+	c.Assert(ioutil.WriteFile(path, []byte("encoding: json\nmessage_key: msg\nfilename: test.log\n"), 0644), IsNil)
+	_, err := GetLogger(path)
+	c.Assert(err, ErrorMatches, `(?s)unmarshal log configuration .*field filename not found.*`)
+}
@@ -0,0 +1,144 @@
+// Copyright (c) 2018 souhup
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logx
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// WatchInterval is how often InitWatch polls the configuration file.
+var WatchInterval = 2 * time.Second
+
+// InitWatch is same as Init, and then it watches the configuration file.
+// Whenever the content of the file changes, it is parsed again and X is
+// reloaded by it, see Logger.Reload. If the file can not be read or the
+// configuration is invalid, the error is passed to onError, or printed on
+// stderr if onError is nil, and X keeps the last valid configuration.
+//
+// Calling stop stops watching.
+func InitWatch(path string, onError func(error)) (stop func(), err error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		err = fmt.Errorf("read log configuration %v, error: %v", path, err)
+		fmt.Fprintln(os.Stderr, err.Error())
+		return
+	}
+	config, err := parseConfig(path, formatOf(path), content)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return
+	}
+	logger, err := GetLoggerByConf(config)
+	if err != nil {
+		return
+	}
+	X = logger
+
+	if onError == nil {
+		onError = func(err error) {
+			fmt.Fprintln(os.Stderr, err.Error())
+		}
+	}
+	w := &watcher{
+		path:    path,
+		logger:  logger,
+		onError: onError,
+		content: content,
+		done:    make(chan struct{}),
+	}
+	go w.run()
+
+	var once sync.Once
+	stop = func() {
+		once.Do(func() { close(w.done) })
+	}
+	return
+}
+
+// watcher polls a configuration file and reloads a Logger by it.
+type watcher struct {
+	path    string
+	logger  *Logger
+	onError func(error)
+	done    chan struct{}
+
+	// the content last loaded, the changed content read by the last poll,
+	// and the error last reported.
+	content []byte
+	pending []byte
+	lastErr string
+}
+
+func (it *watcher) run() {
+	ticker := time.NewTicker(WatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-it.done:
+			return
+		case <-ticker.C:
+			it.check()
+		}
+	}
+}
+
+// check reloads the Logger if the content of the file has changed, and has
+// stayed the same since the last poll, so that a file still being written in
+// place is not parsed. The same error is only reported once.
+func (it *watcher) check() {
+	content, err := ioutil.ReadFile(it.path)
+	if err != nil {
+		it.report(fmt.Errorf("read log configuration %v, error: %v", it.path, err))
+		return
+	}
+	if bytes.Equal(content, it.content) {
+		it.pending = nil
+		return
+	}
+	if !bytes.Equal(content, it.pending) {
+		it.pending = content
+		return
+	}
+
+	config, err := parseConfig(it.path, formatOf(it.path), content)
+	if err == nil {
+		err = it.logger.Reload(config)
+	}
+	if err != nil {
+		it.report(fmt.Errorf("reload log configuration %v, error: %v", it.path, err))
+		return
+	}
+	it.content = content
+	it.lastErr = ""
+}
+
+func (it *watcher) report(err error) {
+	if err.Error() == it.lastErr {
+		return
+	}
+	it.lastErr = err.Error()
+	it.onError(err)
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2018 souhup
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logx
+
+import (
+	"fmt"
+	. "gopkg.in/check.v1"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+)
+
+func (it *MySuite) TestInitWatch(c *C) {
+	defer func(logger *Logger, interval time.Duration) {
+		X, WatchInterval = logger, interval
+	}(X, WatchInterval)
+	WatchInterval = 5 * time.Millisecond
+
+	dir := c.MkDir()
+	path := filepath.Join(dir, "logs.yml")
+	first, second := filepath.Join(dir, "first.log"), filepath.Join(dir, "second.log")
+	writeConfig := func(format string, args ...interface{}) {
+		c.Assert(ioutil.WriteFile(path, []byte(fmt.Sprintf(format, args...)), 0644), IsNil)
+	}
+	keys := "level_key: ''\ntime_key: ''\ncaller_key: ''\n"
+	writeConfig("level: 0\nmessage_key: msg\n%vfile_name: %v\n", keys, first)
+
+	errs := make(chan error, 1)
+	stop, err := InitWatch(path, func(err error) { errs <- err })
+	c.Assert(err, IsNil)
+	defer stop()
+	derived := X.With("a", 1)
+	derived.Info("before")
+
+	writeConfig("level: 1\nmessage_key: message\n%vfile_name: %v\n", keys, second)
+	for i := 0; i < 200 && X.GetLevel() != WarnLevel; i++ {
+		time.Sleep(5 * time.Millisecond)
+	}
+	c.Assert(X.GetLevel(), Equals, WarnLevel)
+	derived.Info("hidden")
+	derived.Warn("after")
+	X.Flush()
+
+	data, err := ioutil.ReadFile(first)
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, `{"msg":"before","a":1}`+"\n")
+	data, err = ioutil.ReadFile(second)
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, `{"message":"after","a":1}`+"\n")
+
+	// an invalid configuration is reported and the last valid one is kept.
+	writeConfig("level: 0\nencoding: xml\n")
+	select {
+	case err = <-errs:
+		c.Assert(err, ErrorMatches, ".*encoding must be one of the json or console.*")
+	case <-time.After(time.Second):
+		c.Fatal("reload error is not reported")
+	}
+	c.Assert(X.GetLevel(), Equals, WarnLevel)
+}
+
+func (it *MySuite) TestWatchPartialWrite(c *C) {
+	dir := c.MkDir()
+	path := filepath.Join(dir, "logs.yml")
+	content := []byte("level: 0\nencoding: json\n")
+	c.Assert(ioutil.WriteFile(path, content, 0644), IsNil)
+	logger, err := GetLogger(path)
+	c.Assert(err, IsNil)
+
+	var errs []error
+	w := &watcher{path: path, logger: logger, onError: func(err error) { errs = append(errs, err) }, content: content}
+
+	// a file caught while being written is not parsed.
+	c.Assert(ioutil.WriteFile(path, []byte("level: 1\nencoding: js"), 0644), IsNil)
+	w.check()
+	c.Assert(ioutil.WriteFile(path, []byte("level: 1\nencoding: json\n"), 0644), IsNil)
+	w.check()
+	c.Assert(errs, HasLen, 0)
+	c.Assert(logger.GetLevel(), Equals, InfoLevel)
+
+	// it is reloaded once the content is the same in two polls.
+	w.check()
+	c.Assert(errs, HasLen, 0)
+	c.Assert(logger.GetLevel(), Equals, WarnLevel)
+}
@@ -0,0 +1,178 @@
+// Copyright (c) 2018 souhup
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logx
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// NopLogger is a Log which discards all entries, such as a fake in tests.
+// Like the Logger, its Panic methods still panic and its Fatal methods still
+// call os.Exit(1).
+type NopLogger struct{}
+
+func (it NopLogger) Show(interface{})                                {}
+func (it NopLogger) Flush()                                          {}
+func (it NopLogger) With(...interface{}) Log                         { return it }
+func (it NopLogger) Withf(string, string, ...interface{}) Log        { return it }
+func (it NopLogger) WithFields(...Field) Log                         { return it }
+func (it NopLogger) Named(string) Log                                { return it }
+func (it NopLogger) Debug(...interface{})                            {}
+func (it NopLogger) Debugf(string, ...interface{})                   {}
+func (it NopLogger) Debugc(context.Context, ...interface{})          {}
+func (it NopLogger) Debugcf(context.Context, string, ...interface{}) {}
+func (it NopLogger) DebugFields(string, ...Field)                    {}
+func (it NopLogger) Info(...interface{})                             {}
+func (it NopLogger) Infof(string, ...interface{})                    {}
+func (it NopLogger) Infoc(context.Context, ...interface{})           {}
+func (it NopLogger) Infocf(context.Context, string, ...interface{})  {}
+func (it NopLogger) InfoFields(string, ...Field)                     {}
+func (it NopLogger) Warn(...interface{})                             {}
+func (it NopLogger) Warnf(string, ...interface{})                    {}
+func (it NopLogger) Warnc(context.Context, ...interface{})           {}
+func (it NopLogger) Warncf(context.Context, string, ...interface{})  {}
+func (it NopLogger) WarnFields(string, ...Field)                     {}
+func (it NopLogger) Error(...interface{})                            {}
+func (it NopLogger) Errorf(string, ...interface{})                   {}
+func (it NopLogger) Errorc(context.Context, ...interface{})          {}
+func (it NopLogger) Errorcf(context.Context, string, ...interface{}) {}
+func (it NopLogger) ErrorFields(string, ...Field)                    {}
+func (it NopLogger) Fatal(...interface{})                            { os.Exit(1) }
+func (it NopLogger) Fatalf(string, ...interface{})                   { os.Exit(1) }
+func (it NopLogger) Fatalc(context.Context, ...interface{})          { os.Exit(1) }
+func (it NopLogger) Fatalcf(context.Context, string, ...interface{}) { os.Exit(1) }
+func (it NopLogger) FatalFields(string, ...Field)                    { os.Exit(1) }
+func (it NopLogger) Panic(v ...interface{})                          { panic(fmt.Sprint(v...)) }
+func (it NopLogger) Panicf(format string, params ...interface{}) {
+	panic(fmt.Sprintf(format, params...))
+}
+func (it NopLogger) Panicc(_ context.Context, v ...interface{}) { panic(fmt.Sprint(v...)) }
+func (it NopLogger) PanicFields(msg string, _ ...Field)         { panic(msg) }
+func (it NopLogger) Withc(ctx context.Context, _ ...interface{}) context.Context {
+	return ctx
+}
+func (it NopLogger) Withcf(ctx context.Context, _, _ string, _ ...interface{}) context.Context {
+	return ctx
+}
+func (it NopLogger) Paniccf(_ context.Context, format string, params ...interface{}) {
+	panic(fmt.Sprintf(format, params...))
+}
+
+// Base is a Log which delegates all methods to Next. A wrapper of Log embeds
+// Base and overrides the methods it decorates, and the overriding methods
+// should call Next rather than Base, so that the caller is reported right.
+// The derived loggers of Base wrap the derived loggers of Next in Base, so a
+// wrapper overrides With, Withf, WithFields and Named to keep itself.
+//
+//	type prefixed struct{ logx.Base }
+//
+//	func (it prefixed) Info(v ...interface{}) {
+//		it.Next.Info(append([]interface{}{"[app]"}, v...)...)
+//	}
+type Base struct {
+	Next Log
+}
+
+// NewBase returns a Base delegating to next. If next is a *Logger, it skips
+// the frame of Base when reporting the caller.
+func NewBase(next Log) Base {
+	if log, ok := next.(*Logger); ok {
+		next = log.WithCallerSkip(1)
+	}
+	return Base{Next: next}
+}
+
+func (it Base) Show(value interface{}) { it.Next.Show(value) }
+func (it Base) Flush()                 { it.Next.Flush() }
+
+func (it Base) With(keysAndValues ...interface{}) Log {
+	return Base{Next: it.Next.With(keysAndValues...)}
+}
+
+func (it Base) Withf(key string, format string, params ...interface{}) Log {
+	return Base{Next: it.Next.Withf(key, format, params...)}
+}
+
+func (it Base) WithFields(fields ...Field) Log {
+	return Base{Next: it.Next.WithFields(fields...)}
+}
+
+func (it Base) Named(name string) Log {
+	return Base{Next: it.Next.Named(name)}
+}
+
+func (it Base) Withc(ctx context.Context, keysAndValues ...interface{}) context.Context {
+	return it.Next.Withc(ctx, keysAndValues...)
+}
+
+func (it Base) Withcf(ctx context.Context, key string, format string, params ...interface{}) context.Context {
+	return it.Next.Withcf(ctx, key, format, params...)
+}
+
+func (it Base) Debug(v ...interface{})                       { it.Next.Debug(v...) }
+func (it Base) Debugf(format string, params ...interface{})  { it.Next.Debugf(format, params...) }
+func (it Base) Debugc(ctx context.Context, v ...interface{}) { it.Next.Debugc(ctx, v...) }
+func (it Base) Debugcf(ctx context.Context, format string, params ...interface{}) {
+	it.Next.Debugcf(ctx, format, params...)
+}
+func (it Base) DebugFields(msg string, fields ...Field) { it.Next.DebugFields(msg, fields...) }
+
+func (it Base) Info(v ...interface{})                       { it.Next.Info(v...) }
+func (it Base) Infof(format string, params ...interface{})  { it.Next.Infof(format, params...) }
+func (it Base) Infoc(ctx context.Context, v ...interface{}) { it.Next.Infoc(ctx, v...) }
+func (it Base) Infocf(ctx context.Context, format string, params ...interface{}) {
+	it.Next.Infocf(ctx, format, params...)
+}
+func (it Base) InfoFields(msg string, fields ...Field) { it.Next.InfoFields(msg, fields...) }
+
+func (it Base) Warn(v ...interface{})                       { it.Next.Warn(v...) }
+func (it Base) Warnf(format string, params ...interface{})  { it.Next.Warnf(format, params...) }
+func (it Base) Warnc(ctx context.Context, v ...interface{}) { it.Next.Warnc(ctx, v...) }
+func (it Base) Warncf(ctx context.Context, format string, params ...interface{}) {
+	it.Next.Warncf(ctx, format, params...)
+}
+func (it Base) WarnFields(msg string, fields ...Field) { it.Next.WarnFields(msg, fields...) }
+
+func (it Base) Error(v ...interface{})                       { it.Next.Error(v...) }
+func (it Base) Errorf(format string, params ...interface{})  { it.Next.Errorf(format, params...) }
+func (it Base) Errorc(ctx context.Context, v ...interface{}) { it.Next.Errorc(ctx, v...) }
+func (it Base) Errorcf(ctx context.Context, format string, params ...interface{}) {
+	it.Next.Errorcf(ctx, format, params...)
+}
+func (it Base) ErrorFields(msg string, fields ...Field) { it.Next.ErrorFields(msg, fields...) }
+
+func (it Base) Fatal(v ...interface{})                       { it.Next.Fatal(v...) }
+func (it Base) Fatalf(format string, params ...interface{})  { it.Next.Fatalf(format, params...) }
+func (it Base) Fatalc(ctx context.Context, v ...interface{}) { it.Next.Fatalc(ctx, v...) }
+func (it Base) Fatalcf(ctx context.Context, format string, params ...interface{}) {
+	it.Next.Fatalcf(ctx, format, params...)
+}
+func (it Base) FatalFields(msg string, fields ...Field) { it.Next.FatalFields(msg, fields...) }
+
+func (it Base) Panic(v ...interface{})                       { it.Next.Panic(v...) }
+func (it Base) Panicf(format string, params ...interface{})  { it.Next.Panicf(format, params...) }
+func (it Base) Panicc(ctx context.Context, v ...interface{}) { it.Next.Panicc(ctx, v...) }
+func (it Base) Paniccf(ctx context.Context, format string, params ...interface{}) {
+	it.Next.Paniccf(ctx, format, params...)
+}
+func (it Base) PanicFields(msg string, fields ...Field) { it.Next.PanicFields(msg, fields...) }
@@ -0,0 +1,107 @@
+// Copyright (c) 2018 souhup
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package logx
+
+import (
+	"context"
+	"fmt"
+	. "gopkg.in/check.v1"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	_ Log = (*Logger)(nil)
+	_ Log = NopLogger{}
+	_ Log = Base{}
+)
+
+func (it *MySuite) TestNopLogger(c *C) {
+	var log Log = NopLogger{}
+	log = log.With("a", 1).Withf("b", "%v", 2).WithFields(Int("c", 3)).Named("nop")
+	log.Info("discarded")
+	log.InfoFields("discarded")
+	ctx := context.Background()
+	c.Assert(log.Withc(ctx, "a", 1), Equals, ctx)
+	c.Assert(func() { log.Panicf("%v", "panicked") }, PanicMatches, "panicked")
+}
+
+// prefixed is a wrapper of Log adding a prefix to messages of Info.
+type prefixed struct {
+	Base
+}
+
+func (it prefixed) Info(v ...interface{}) {
+	it.Next.Info(append([]interface{}{"[app]"}, v...)...)
+}
+
+func (it prefixed) With(keysAndValues ...interface{}) Log {
+	return prefixed{Base{Next: it.Next.With(keysAndValues...)}}
+}
+
+func (it *MySuite) TestBase(c *C) {
+	filename := filepath.Join(c.MkDir(), "base.log")
+	logger, err := GetLoggerByConf(&Config{MessageKey: "msg", CallerKey: "caller", Encoding: "json", Filename: filename})
+	c.Assert(err, IsNil)
+
+	log := prefixed{NewBase(logger)}
+	below := callersBelow()
+	log.Info("overridden")
+	log.Warn("delegated")
+	log.With("a", 1).Info("derived")
+	log.Named("named").InfoFields("fields")
+	ctx := log.Withc(context.Background(), "b", 2)
+	log.Warnc(ctx, "context")
+	FromContext(ctx).Warn("from context")
+	logger.Warnc(ctx, "by Logger")
+	logger.Flush()
+
+	data, err := ioutil.ReadFile(filename)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Split(strings.TrimSpace(string(data)), "\n"), DeepEquals, []string{
+		`{"caller":"` + below(1) + `","msg":"[app] overridden"}`,
+		`{"caller":"` + below(2) + `","msg":"delegated"}`,
+		`{"caller":"` + below(3) + `","msg":"[app] derived","a":1}`,
+		`{"caller":"` + below(4) + `","msg":"fields"}`,
+		`{"caller":"` + below(6) + `","msg":"context","b":2}`,
+		`{"caller":"` + below(7) + `","msg":"from context","b":2}`,
+		`{"caller":"` + below(8) + `","msg":"by Logger","b":2}`,
+	})
+}
+
+// recorder is a fake Log recording the messages of Info.
+type recorder struct {
+	Base
+	messages *[]string
+}
+
+func (it recorder) Info(v ...interface{}) {
+	*it.messages = append(*it.messages, fmt.Sprint(v...))
+}
+
+func (it *MySuite) TestFakeInContext(c *C) {
+	var messages []string
+	ctx := NewContext(context.Background(), recorder{Base{Next: NopLogger{}}, &messages})
+	X.Infoc(ctx, "hello", 1)
+	FromContext(ctx).Info("world")
+	c.Assert(messages, DeepEquals, []string{"hello 1", "world"})
+}